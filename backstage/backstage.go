@@ -0,0 +1,396 @@
+// Package backstage converts between Backstage software catalog entities and
+// c4 elements.
+//
+// Importing reads catalog-info.yaml descriptors and creates an element per
+// entity: System entities become systems, Component entities become
+// containers, Resource entities become databases or queues and Group and User
+// entities become people. The dependsOn, providesApis and consumesApis fields
+// become relations, while the system and subcomponentOf fields are used to
+// group elements within boundaries:
+//
+//	res, err := backstage.ImportFiles(ctx, backstage.ImportArgs{}, "catalog-info.yaml")
+//	if err != nil {
+//		return err
+//	}
+//
+//	d, err := res.Diagram(ctx, "Payments")
+//	if err != nil {
+//		return err
+//	}
+//	d.PlantUML(ctx, os.Stdout)
+//
+// Exporting performs the reverse conversion so that catalog entries can be
+// seeded from an existing diagram. See Export for details.
+package backstage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/haleyrc/c4"
+	"github.com/haleyrc/c4/internal/alias"
+)
+
+// ExternalTag is the entity tag used to mark elements as external.
+const ExternalTag = "external"
+
+// DefaultTypes maps the spec.type of Component and Resource entities to the
+// kind of element they become. Components with an unknown type become
+// containers and resources with an unknown type become databases.
+var DefaultTypes = map[string]c4.Kind{
+	"service": c4.KindContainer,
+	"website": c4.KindContainer,
+	"library": c4.KindComponent,
+
+	"cache":    c4.KindDatabase,
+	"database": c4.KindDatabase,
+	"queue":    c4.KindQueue,
+	"stream":   c4.KindQueue,
+	"topic":    c4.KindQueue,
+}
+
+// apiTechnologies maps the spec.type of API entities to the technology shown on
+// relations that use them.
+var apiTechnologies = map[string]string{
+	"asyncapi": "AsyncAPI",
+	"graphql":  "GraphQL",
+	"grpc":     "gRPC",
+	"openapi":  "HTTP",
+	"trpc":     "tRPC",
+}
+
+// ImportArgs describes the parameters available for importing catalog
+// entities.
+type ImportArgs struct {
+	// Additional type mappings that take precedence over DefaultTypes.
+	Types map[string]c4.Kind
+}
+
+// Result holds the elements generated from a set of entities.
+type Result struct {
+	// The generated elements in the order their entities were declared.
+	Elements []c4.Element
+
+	// Relations derived from dependencies and API usage.
+	Relations []c4.RelationArgs
+
+	refs     map[string]c4.Element
+	members  map[c4.Element][]c4.Element
+	parentOf map[c4.Element]c4.Element
+}
+
+// ImportFiles is the same as Import, but reads the entities from each of the
+// named files.
+func ImportFiles(ctx context.Context, args ImportArgs, names ...string) (*Result, error) {
+	var ents []entity
+	for _, name := range names {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, fmt.Errorf("backstage: import: %w", err)
+		}
+		fileEnts, err := decode(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("backstage: import: %s: %w", name, err)
+		}
+		ents = append(ents, fileEnts...)
+	}
+	return convert(ctx, ents, args)
+}
+
+// Import reads catalog entities from r and converts them into elements.
+func Import(ctx context.Context, r io.Reader, args ImportArgs) (*Result, error) {
+	ents, err := decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("backstage: import: %w", err)
+	}
+	return convert(ctx, ents, args)
+}
+
+// Lookup returns the element created for an entity reference e.g.
+// "component:default/payments-api". References must include the kind, but may
+// omit the namespace.
+func (r *Result) Lookup(ref string) (c4.Element, bool) {
+	el, ok := r.refs[parseRef(ref, "", defaultNamespace)]
+	return el, ok
+}
+
+// Members returns the elements grouped within el, either because they belong
+// to a system or are a subcomponent of a component.
+func (r *Result) Members(el c4.Element) []c4.Element {
+	return r.members[el]
+}
+
+// Diagram constructs a diagram containing every element and relation. Systems
+// and containers with members are added as boundaries containing those
+// members. The members of other elements, such as the subcomponents of a
+// library, are added alongside them.
+func (r *Result) Diagram(ctx context.Context, title string, opts ...c4.DiagramOption) (*c4.Diagram, error) {
+	d, err := c4.NewDiagram(ctx, title, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, el := range r.Elements {
+		if r.parentOf[el] == nil {
+			r.addElement(ctx, d, el)
+		}
+	}
+
+	for _, args := range r.Relations {
+		if err := d.NewRelation(ctx, args); err != nil {
+			return nil, err
+		}
+	}
+
+	return d, nil
+}
+
+type elementAdder interface {
+	AddElement(ctx context.Context, el c4.Element)
+}
+
+func (r *Result) addElement(ctx context.Context, parent elementAdder, el c4.Element) {
+	members := r.members[el]
+	bounded, ok := el.(interface{ Boundary() c4.Boundary })
+	if len(members) == 0 || !ok {
+		parent.AddElement(ctx, el)
+		// Elements that can't be drawn as a boundary, such as components,
+		// have their members drawn alongside them instead.
+		for _, m := range members {
+			r.addElement(ctx, parent, m)
+		}
+		return
+	}
+
+	b := bounded.Boundary()
+	for _, m := range members {
+		r.addElement(ctx, b, m)
+	}
+	parent.AddElement(ctx, b)
+}
+
+// nestedWithin reports whether el appears in the chain of parents starting at
+// p. This prevents entities that reference each other from disappearing into a
+// cycle.
+func (r *Result) nestedWithin(p, el c4.Element) bool {
+	for ; p != nil; p = r.parentOf[p] {
+		if p == el {
+			return true
+		}
+	}
+	return false
+}
+
+func convert(ctx context.Context, ents []entity, args ImportArgs) (*Result, error) {
+	types := map[string]c4.Kind{}
+	for k, v := range DefaultTypes {
+		types[k] = v
+	}
+	for k, v := range args.Types {
+		types[k] = v
+	}
+
+	names := map[string]int{}
+	for _, ent := range ents {
+		if _, ok := elementKind(ent, types); ok {
+			names[strings.ToLower(ent.Metadata.Name)]++
+		}
+	}
+
+	res := &Result{
+		refs:     map[string]c4.Element{},
+		members:  map[c4.Element][]c4.Element{},
+		parentOf: map[c4.Element]c4.Element{},
+	}
+	apis := map[string]entity{}
+	for _, ent := range ents {
+		kind, ok := elementKind(ent, types)
+		if !ok {
+			if strings.EqualFold(ent.Kind, "API") {
+				apis[ent.ref()] = ent
+			}
+			continue
+		}
+
+		// Names only need to be unique per kind and namespace, so both are
+		// included in the identifier when a name is shared.
+		id := alias.Make(ent.Metadata.Name)
+		if names[strings.ToLower(ent.Metadata.Name)] > 1 {
+			id = alias.Make(ent.Kind, ent.Metadata.Namespace, ent.Metadata.Name)
+		}
+
+		el, err := newElement(ctx, id, kind, ent)
+		if err != nil {
+			return nil, err
+		}
+		res.Elements = append(res.Elements, el)
+		res.refs[ent.ref()] = el
+	}
+
+	providers := map[string][]c4.Element{}
+	for _, ent := range ents {
+		el, ok := res.refs[ent.ref()]
+		if !ok {
+			continue
+		}
+		for _, api := range ent.Spec.ProvidesAPIs {
+			ref := parseRef(api, "api", ent.Metadata.Namespace)
+			providers[ref] = append(providers[ref], el)
+		}
+	}
+
+	for _, ent := range ents {
+		el, ok := res.refs[ent.ref()]
+		if !ok {
+			continue
+		}
+		ns := ent.Metadata.Namespace
+
+		// Subcomponents are grouped within their parent component in
+		// preference to their system.
+		for _, parent := range []string{
+			parseRef(ent.Spec.SubcomponentOf, "component", ns),
+			parseRef(ent.Spec.System, "system", ns),
+		} {
+			p, ok := res.refs[parent]
+			if !ok || res.parentOf[el] != nil || res.nestedWithin(p, el) {
+				continue
+			}
+			res.members[p] = append(res.members[p], el)
+			res.parentOf[el] = p
+		}
+
+		for _, dep := range ent.Spec.DependsOn {
+			dst, ok := res.refs[parseRef(dep, "component", ns)]
+			if !ok {
+				continue
+			}
+			res.Relations = append(res.Relations, c4.RelationArgs{
+				Src:         el,
+				Dst:         dst,
+				Description: "Depends on",
+			})
+		}
+
+		for _, api := range ent.Spec.ConsumesAPIs {
+			ref := parseRef(api, "api", ns)
+			var technologies []string
+			if tech, ok := apiTechnologies[strings.ToLower(apis[ref].Spec.Type)]; ok {
+				technologies = []string{tech}
+			}
+			name := apis[ref].title()
+			if name == "" {
+				name = api
+			}
+			for _, dst := range providers[ref] {
+				res.Relations = append(res.Relations, c4.RelationArgs{
+					Src:          el,
+					Dst:          dst,
+					Description:  "Uses",
+					Technologies: technologies,
+					Properties:   []c4.Property{{Name: "API", Value: name}},
+				})
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// elementKind returns the kind of element an entity is converted to. Entities
+// without an element representation, such as APIs and domains, return false.
+func elementKind(ent entity, types map[string]c4.Kind) (c4.Kind, bool) {
+	switch strings.ToLower(ent.Kind) {
+	case "system":
+		return c4.KindSystem, true
+	case "group", "user":
+		return c4.KindPerson, true
+	case "component":
+		if kind, ok := types[strings.ToLower(ent.Spec.Type)]; ok {
+			return kind, true
+		}
+		return c4.KindContainer, true
+	case "resource":
+		if kind, ok := types[strings.ToLower(ent.Spec.Type)]; ok {
+			return kind, true
+		}
+		return c4.KindDatabase, true
+	}
+	return "", false
+}
+
+func newElement(ctx context.Context, id string, kind c4.Kind, ent entity) (c4.Element, error) {
+	name := ent.title()
+	description := ent.Metadata.Description
+
+	var technologies []string
+	external := false
+	for _, tag := range ent.Metadata.Tags {
+		if tag == ExternalTag {
+			external = true
+			continue
+		}
+		technologies = append(technologies, tag)
+	}
+
+	var properties []c4.Property
+	if ent.Spec.Owner != "" {
+		properties = append(properties, c4.Property{Name: "Owner", Value: ent.Spec.Owner})
+	}
+	if ent.Spec.Lifecycle != "" {
+		properties = append(properties, c4.Property{Name: "Lifecycle", Value: ent.Spec.Lifecycle})
+	}
+
+	switch kind {
+	case c4.KindPerson:
+		return c4.NewPerson(ctx, id, c4.PersonArgs{
+			Name:        name,
+			Description: description,
+			External:    external,
+		})
+	case c4.KindSystem:
+		return c4.NewSystem(ctx, id, c4.SystemArgs{
+			Name:        name,
+			Description: description,
+			External:    external,
+			Properties:  properties,
+		})
+	case c4.KindContainer:
+		return c4.NewContainer(ctx, id, c4.ContainerArgs{
+			Name:         name,
+			Description:  description,
+			Technologies: technologies,
+			External:     external,
+			Properties:   properties,
+		})
+	case c4.KindComponent:
+		return c4.NewComponent(ctx, id, c4.ComponentArgs{
+			Name:         name,
+			Description:  description,
+			Technologies: technologies,
+			External:     external,
+			Properties:   properties,
+		})
+	case c4.KindDatabase:
+		return c4.NewDatabase(ctx, id, c4.DatabaseArgs{
+			Name:         name,
+			Description:  description,
+			Technologies: technologies,
+			External:     external,
+			Properties:   properties,
+		})
+	case c4.KindQueue:
+		return c4.NewQueue(ctx, id, c4.QueueArgs{
+			Name:         name,
+			Description:  description,
+			Technologies: technologies,
+			External:     external,
+			Properties:   properties,
+		})
+	}
+	return nil, fmt.Errorf("backstage: invalid kind %q for %s", kind, ent.ref())
+}
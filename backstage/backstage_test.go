@@ -0,0 +1,135 @@
+package backstage
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/haleyrc/c4"
+)
+
+// layout describes the elements of d, one per line, by their path through the
+// enclosing boundaries and their kind e.g. "payments/payments_api container".
+func layout(d *c4.Diagram) string {
+	var lines []string
+	var visit func(prefix string, els []c4.Element)
+	visit = func(prefix string, els []c4.Element) {
+		for _, el := range els {
+			lines = append(lines, prefix+el.ID()+" "+string(c4.KindOf(el)))
+			if b, ok := el.(interface{ Elements() []c4.Element }); ok {
+				visit(prefix+el.ID()+"/", b.Elements())
+			}
+		}
+	}
+	visit("", d.Elements())
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+// relations describes the relations of d, one per line.
+func relations(d *c4.Diagram) string {
+	var lines []string
+	for _, rel := range d.Relations() {
+		lines = append(lines, rel.Src.ID()+" -> "+rel.Dst.ID())
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+func TestRoundTrip(t *testing.T) {
+	ctx := context.Background()
+
+	res, err := ImportFiles(ctx, ImportArgs{}, filepath.Join("testdata", "catalog.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := res.Diagram(ctx, "Payments")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The subcomponents of a library are drawn beside it, since components
+	// can't be drawn as boundaries.
+	wantLayout := strings.Join([]string{
+		"billing system",
+		"payments systemBoundary",
+		"payments/ledger component",
+		"payments/ledger_rules component",
+		"payments/payments_api containerBoundary",
+		"payments/payments_api/validator component",
+		"payments/payments_db database",
+	}, "\n")
+	if got := layout(d); got != wantLayout {
+		t.Errorf("got layout:\n%s\nwant:\n%s", got, wantLayout)
+	}
+	wantRelations := strings.Join([]string{
+		"ledger_rules -> payments_db",
+		"payments_api -> billing",
+		"payments_api -> ledger",
+		"payments_api -> payments_db",
+	}, "\n")
+	if got := relations(d); got != wantRelations {
+		t.Errorf("got relations:\n%s\nwant:\n%s", got, wantRelations)
+	}
+
+	var buff bytes.Buffer
+	if err := Export(ctx, &buff, d, ExportArgs{}); err != nil {
+		t.Fatal(err)
+	}
+
+	ents, err := decode(bytes.NewReader(buff.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ent := range ents {
+		for _, dep := range ent.Spec.DependsOn {
+			if strings.HasPrefix(dep, "system:") {
+				t.Errorf("%s depends on %s, but systems can't be dependencies", ent.ref(), dep)
+			}
+		}
+		if ent.Metadata.Name == "ledger_rules" && ent.Spec.System != "payments" {
+			t.Errorf("got system %q for ledger_rules, want %q", ent.Spec.System, "payments")
+		}
+	}
+
+	res, err = Import(ctx, bytes.NewReader(buff.Bytes()), ImportArgs{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err = res.Diagram(ctx, "Payments")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The dependency on a system has no catalog equivalent, so it is the only
+	// thing lost.
+	if got := layout(d); got != wantLayout {
+		t.Errorf("got layout after export:\n%s\nwant:\n%s", got, wantLayout)
+	}
+	wantRelations = strings.Join([]string{
+		"ledger_rules -> payments_db",
+		"payments_api -> ledger",
+		"payments_api -> payments_db",
+	}, "\n")
+	if got := relations(d); got != wantRelations {
+		t.Errorf("got relations after export:\n%s\nwant:\n%s", got, wantRelations)
+	}
+
+	api, ok := res.Lookup("component:payments_api")
+	if !ok {
+		t.Fatal("payments_api wasn't imported")
+	}
+	if got := api.(*c4.Container).Name(); got != "Payments API" {
+		t.Errorf("got name %q, want %q", got, "Payments API")
+	}
+	billing, ok := res.Lookup("system:billing")
+	if !ok {
+		t.Fatal("billing wasn't imported")
+	}
+	if !billing.(*c4.System).External() {
+		t.Error("billing isn't external after export")
+	}
+}
@@ -0,0 +1,109 @@
+package backstage
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	apiVersion       = "backstage.io/v1alpha1"
+	defaultNamespace = "default"
+)
+
+// entity holds the subset of a catalog entity used to build elements. Fields
+// are shared between kinds where the descriptor formats agree.
+type entity struct {
+	APIVersion string   `yaml:"apiVersion"`
+	Kind       string   `yaml:"kind"`
+	Metadata   metadata `yaml:"metadata"`
+	Spec       spec     `yaml:"spec"`
+}
+
+type metadata struct {
+	Name        string   `yaml:"name"`
+	Namespace   string   `yaml:"namespace,omitempty"`
+	Title       string   `yaml:"title,omitempty"`
+	Description string   `yaml:"description,omitempty"`
+	Tags        []string `yaml:"tags,omitempty"`
+}
+
+type spec struct {
+	// Component, Resource and API
+	Type      string `yaml:"type"`
+	Lifecycle string `yaml:"lifecycle"`
+	Owner     string `yaml:"owner"`
+	System    string `yaml:"system"`
+
+	// Component and Resource
+	SubcomponentOf string   `yaml:"subcomponentOf"`
+	DependsOn      []string `yaml:"dependsOn"`
+	ProvidesAPIs   []string `yaml:"providesApis"`
+	ConsumesAPIs   []string `yaml:"consumesApis"`
+
+	// Group and User
+	Profile struct {
+		DisplayName string `yaml:"displayName"`
+	} `yaml:"profile"`
+}
+
+// ref returns the full reference of the entity e.g. "component:default/api".
+func (e entity) ref() string {
+	return makeRef(e.Kind, e.Metadata.Namespace, e.Metadata.Name)
+}
+
+// title returns the human-readable name of the entity.
+func (e entity) title() string {
+	if e.Spec.Profile.DisplayName != "" {
+		return e.Spec.Profile.DisplayName
+	}
+	if e.Metadata.Title != "" {
+		return e.Metadata.Title
+	}
+	return e.Metadata.Name
+}
+
+// parseRef expands a possibly abbreviated entity reference of the form
+// "[kind:][namespace/]name" into a full reference. The kind defaults to
+// defaultKind and the namespace defaults to the namespace of the referencing
+// entity.
+func parseRef(ref, defaultKind, namespace string) string {
+	kind := defaultKind
+	if k, rest, ok := strings.Cut(ref, ":"); ok {
+		kind, ref = k, rest
+	}
+	if ns, name, ok := strings.Cut(ref, "/"); ok {
+		namespace, ref = ns, name
+	}
+	return makeRef(kind, namespace, ref)
+}
+
+func makeRef(kind, namespace, name string) string {
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	return strings.ToLower(kind + ":" + namespace + "/" + name)
+}
+
+// decode reads every entity in a multi-document YAML or JSON stream.
+func decode(r io.Reader) ([]entity, error) {
+	var ents []entity
+
+	dec := yaml.NewDecoder(r)
+	for {
+		var ent entity
+		if err := dec.Decode(&ent); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		if ent.Kind != "" && ent.Metadata.Name != "" {
+			ents = append(ents, ent)
+		}
+	}
+
+	return ents, nil
+}
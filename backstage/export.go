@@ -0,0 +1,325 @@
+package backstage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/haleyrc/c4"
+)
+
+// DefaultLifecycle is the lifecycle of exported entities without a Lifecycle
+// property.
+const DefaultLifecycle = "production"
+
+// ExportArgs describes the parameters available for exporting catalog
+// entities.
+type ExportArgs struct {
+	// The namespace of the exported entities. Defaults to the default
+	// namespace.
+	Namespace string
+
+	// The owner of exported entities without an Owner property e.g.
+	// "group:payments-team". Backstage requires an owner for systems,
+	// components and resources, so exporting fails if neither is provided.
+	Owner string
+
+	// The lifecycle of exported entities without a Lifecycle property.
+	// Defaults to DefaultLifecycle.
+	Lifecycle string
+}
+
+type exportEntity struct {
+	APIVersion string      `yaml:"apiVersion"`
+	Kind       string      `yaml:"kind"`
+	Metadata   metadata    `yaml:"metadata"`
+	Spec       interface{} `yaml:"spec"`
+}
+
+type groupSpec struct {
+	Type    string `yaml:"type"`
+	Profile struct {
+		DisplayName string `yaml:"displayName,omitempty"`
+	} `yaml:"profile,omitempty"`
+	Children []string `yaml:"children"`
+}
+
+type systemSpec struct {
+	Owner string `yaml:"owner"`
+}
+
+type componentSpec struct {
+	Type           string   `yaml:"type"`
+	Lifecycle      string   `yaml:"lifecycle,omitempty"`
+	Owner          string   `yaml:"owner"`
+	System         string   `yaml:"system,omitempty"`
+	SubcomponentOf string   `yaml:"subcomponentOf,omitempty"`
+	DependsOn      []string `yaml:"dependsOn,omitempty"`
+}
+
+// Export writes a catalog entity for each element in d to w as a
+// multi-document YAML stream:
+//
+//   - People become Group entities with the type "persona".
+//   - Systems and system boundaries become System entities.
+//   - Containers become Component entities with the type "service" and
+//     components become Component entities with the type "library" that are a
+//     subcomponent of their enclosing container.
+//   - Databases and queues become Resource entities with the type "database"
+//     or "queue".
+//
+// Elements within a system boundary are assigned to that system and relations
+// from components and resources to other components and resources are recorded
+// as dependencies. Entity names are
+// derived from element identifiers and technologies are recorded as tags.
+// Deployment elements have no catalog equivalent and are skipped.
+func Export(ctx context.Context, w io.Writer, d *c4.Diagram, args ExportArgs) error {
+	ex := &exporter{
+		args:     args,
+		entities: map[string]*exportEntity{},
+	}
+	if ex.args.Lifecycle == "" {
+		ex.args.Lifecycle = DefaultLifecycle
+	}
+
+	if err := ex.addElements(d.Elements(), nil, nil); err != nil {
+		return fmt.Errorf("backstage: export: %w", err)
+	}
+
+	for _, rel := range d.Relations() {
+		src, ok := ex.entities[rel.Src.ID()]
+		if !ok {
+			continue
+		}
+		// Components can only depend on other components and resources.
+		dst, ok := ex.entities[rel.Dst.ID()]
+		if !ok || dst.Kind == "Group" || dst.Kind == "System" {
+			continue
+		}
+		if spec, ok := src.Spec.(*componentSpec); ok {
+			ref := ex.ref(dst)
+			if !contains(spec.DependsOn, ref) {
+				spec.DependsOn = append(spec.DependsOn, ref)
+			}
+		}
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	for _, id := range ex.order {
+		if err := enc.Encode(ex.entities[id]); err != nil {
+			return fmt.Errorf("backstage: export: %w", err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("backstage: export: %w", err)
+	}
+
+	return nil
+}
+
+type exporter struct {
+	args     ExportArgs
+	entities map[string]*exportEntity
+	order    []string
+}
+
+// describable is implemented by every element that can be exported.
+type describable interface {
+	c4.Element
+	Name() string
+	Description() string
+	External() bool
+	Properties() []c4.Property
+}
+
+// addElements exports els and their children. The system and container refer
+// to the enclosing boundaries, if any.
+func (ex *exporter) addElements(els []c4.Element, system, container *exportEntity) error {
+	for _, el := range els {
+		if _, seen := ex.entities[el.ID()]; seen {
+			continue
+		}
+
+		kind := c4.KindOf(el)
+		switch kind {
+		case c4.KindDeploymentNode, c4.KindInfrastructureNode, c4.KindInstance:
+			continue
+		case c4.KindEnterpriseBoundary:
+			if err := ex.addElements(el.(*c4.EnterpriseBoundary).Elements(), system, container); err != nil {
+				return err
+			}
+			continue
+		}
+
+		desc, ok := el.(describable)
+		if !ok {
+			continue
+		}
+
+		ent, err := ex.newEntity(kind, desc, system, container)
+		if err != nil {
+			return err
+		}
+		ex.entities[el.ID()] = ent
+		ex.order = append(ex.order, el.ID())
+
+		if b, ok := el.(interface{ Elements() []c4.Element }); ok {
+			switch kind {
+			case c4.KindSystemBoundary:
+				err = ex.addElements(b.Elements(), ent, container)
+			case c4.KindContainerBoundary:
+				err = ex.addElements(b.Elements(), system, ent)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (ex *exporter) newEntity(kind c4.Kind, el describable, system, container *exportEntity) (*exportEntity, error) {
+	ent := &exportEntity{
+		APIVersion: apiVersion,
+		Metadata: metadata{
+			Name:        entityName(el.ID()),
+			Namespace:   ex.args.Namespace,
+			Description: el.Description(),
+		},
+	}
+	if el.Name() != ent.Metadata.Name {
+		ent.Metadata.Title = el.Name()
+	}
+
+	if t, ok := el.(interface{ Technologies() []string }); ok {
+		for _, tech := range t.Technologies() {
+			if tag := entityTag(tech); tag != "" && !contains(ent.Metadata.Tags, tag) {
+				ent.Metadata.Tags = append(ent.Metadata.Tags, tag)
+			}
+		}
+	}
+	if el.External() {
+		ent.Metadata.Tags = append(ent.Metadata.Tags, ExternalTag)
+	}
+
+	if kind == c4.KindPerson {
+		ent.Kind = "Group"
+		spec := &groupSpec{Type: "persona", Children: []string{}}
+		spec.Profile.DisplayName = el.Name()
+		ent.Spec = spec
+		return ent, nil
+	}
+
+	owner := property(el.Properties(), "Owner")
+	if owner == "" {
+		owner = ex.args.Owner
+	}
+	if owner == "" {
+		return nil, fmt.Errorf("%s: an owner is required", el.ID())
+	}
+
+	if kind == c4.KindSystem || kind == c4.KindSystemBoundary {
+		ent.Kind = "System"
+		ent.Spec = &systemSpec{Owner: owner}
+		return ent, nil
+	}
+
+	lifecycle := property(el.Properties(), "Lifecycle")
+	if lifecycle == "" {
+		lifecycle = ex.args.Lifecycle
+	}
+
+	spec := &componentSpec{Owner: owner}
+	if system != nil {
+		spec.System = system.Metadata.Name
+	}
+
+	switch kind {
+	case c4.KindContainer, c4.KindContainerBoundary:
+		ent.Kind = "Component"
+		spec.Type = "service"
+		spec.Lifecycle = lifecycle
+	case c4.KindComponent:
+		ent.Kind = "Component"
+		spec.Type = "library"
+		spec.Lifecycle = lifecycle
+		if container != nil {
+			spec.SubcomponentOf = ex.ref(container)
+		}
+	case c4.KindDatabase, c4.KindDatabaseBoundary:
+		ent.Kind = "Resource"
+		spec.Type = "database"
+	case c4.KindQueue:
+		ent.Kind = "Resource"
+		spec.Type = "queue"
+	default:
+		return nil, fmt.Errorf("%s: cannot export %s", el.ID(), kind)
+	}
+	ent.Spec = spec
+
+	return ent, nil
+}
+
+// ref returns an abbreviated reference to an exported entity e.g.
+// "component:payments-api".
+func (ex *exporter) ref(ent *exportEntity) string {
+	ref := strings.ToLower(ent.Kind) + ":"
+	if ex.args.Namespace != "" {
+		ref += ex.args.Namespace + "/"
+	}
+	return ref + ent.Metadata.Name
+}
+
+var (
+	invalidNameRE = regexp.MustCompile(`[^a-zA-Z0-9_.\-]+`)
+	separatorsRE  = regexp.MustCompile(`[-_.]{2,}`)
+	invalidTagRE  = regexp.MustCompile(`[^a-z0-9:+#]+`)
+)
+
+// entityName converts an element identifier into a valid entity name, which
+// consists of alphanumeric sequences separated by -, _ or . and is limited to
+// 63 characters.
+func entityName(id string) string {
+	name := invalidNameRE.ReplaceAllString(id, "-")
+	name = separatorsRE.ReplaceAllStringFunc(name, func(s string) string { return s[:1] })
+	name = strings.Trim(name, "-_.")
+	if len(name) > 63 {
+		name = strings.TrimRight(name[:63], "-_.")
+	}
+	return name
+}
+
+// entityTag converts a technology into a valid entity tag, which consists of
+// lowercase alphanumeric sequences separated by dashes e.g. "Spring MVC"
+// becomes "spring-mvc".
+func entityTag(tech string) string {
+	tag := invalidTagRE.ReplaceAllString(strings.ToLower(tech), "-")
+	tag = strings.Trim(tag, "-")
+	if len(tag) > 63 {
+		tag = strings.TrimRight(tag[:63], "-")
+	}
+	return tag
+}
+
+func property(props []c4.Property, name string) string {
+	for _, p := range props {
+		if strings.EqualFold(p.Name, name) {
+			return p.Value
+		}
+	}
+	return ""
+}
+
+func contains(s []string, v string) bool {
+	for _, el := range s {
+		if el == v {
+			return true
+		}
+	}
+	return false
+}
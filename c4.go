@@ -18,3 +18,112 @@ type Boundary interface {
 type Element interface {
 	ID() string
 }
+
+// Property represents a key/value pair decribing an aspect of an element or
+// relation e.g. an owner or SLA. Properties are displayed as a table within the
+// element in the resultant diagram.
+type Property struct {
+	Name  string
+	Value string
+}
+
+// Kind identifies the type of an element.
+type Kind string
+
+const (
+	KindComponent          Kind = "component"
+	KindContainer          Kind = "container"
+	KindContainerBoundary  Kind = "containerBoundary"
+	KindDatabase           Kind = "database"
+	KindDatabaseBoundary   Kind = "databaseBoundary"
+	KindDeploymentNode     Kind = "deploymentNode"
+	KindEnterpriseBoundary Kind = "enterpriseBoundary"
+	KindInfrastructureNode Kind = "infrastructureNode"
+	KindInstance           Kind = "instance"
+	KindPerson             Kind = "person"
+	KindQueue              Kind = "queue"
+	KindSystem             Kind = "system"
+	KindSystemBoundary     Kind = "systemBoundary"
+)
+
+// KindOf returns the kind of el. The zero value is returned for element types
+// not defined by this package.
+func KindOf(el Element) Kind {
+	switch el.(type) {
+	case *Component:
+		return KindComponent
+	case *Container:
+		return KindContainer
+	case *containerBoundary:
+		return KindContainerBoundary
+	case *Database:
+		return KindDatabase
+	case *databaseBoundary:
+		return KindDatabaseBoundary
+	case *DeploymentNode:
+		return KindDeploymentNode
+	case *EnterpriseBoundary:
+		return KindEnterpriseBoundary
+	case *InfrastructureNode:
+		return KindInfrastructureNode
+	case *Instance:
+		return KindInstance
+	case *Person:
+		return KindPerson
+	case *Queue:
+		return KindQueue
+	case *System:
+		return KindSystem
+	case *systemBoundary:
+		return KindSystemBoundary
+	}
+	return ""
+}
+
+// summary describes an element for display.
+type summary struct {
+	name         string
+	kind         string
+	technologies []string
+	external     bool
+	description  string
+}
+
+// summarize returns the details of el that are displayed by the text-based
+// formats. Boundaries are described as the element they were created from, and
+// instances as the element they are an instance of.
+func summarize(el Element) summary {
+	switch v := el.(type) {
+	case *Component:
+		return summary{v.name, v.shape.describe("Component"), v.technologies, v.external, v.description}
+	case *Container:
+		return summary{v.name, "Container", v.technologies, v.external, v.description}
+	case *containerBoundary:
+		return summary{v.name, "Container", v.technologies, v.external, v.description}
+	case *Database:
+		return summary{v.name, "Container (Database)", v.technologies, v.external, v.description}
+	case *databaseBoundary:
+		return summary{v.name, "Container (Database)", v.technologies, v.external, v.description}
+	case *DeploymentNode:
+		var technologies []string
+		if v.nodeType != "" {
+			technologies = []string{v.nodeType}
+		}
+		return summary{v.name, "Deployment Node", technologies, false, v.description}
+	case *EnterpriseBoundary:
+		return summary{v.name, "Enterprise", nil, false, ""}
+	case *InfrastructureNode:
+		return summary{v.name, "Infrastructure Node", v.technologies, false, v.description}
+	case *Instance:
+		return summarize(v.Of())
+	case *Person:
+		return summary{v.name, "Person", nil, v.external, v.description}
+	case *Queue:
+		return summary{v.name, "Container (Queue)", v.technologies, v.external, v.description}
+	case *System:
+		return summary{v.name, v.shape.describe("Software System"), nil, v.external, v.description}
+	case *systemBoundary:
+		return summary{v.name, v.shape.describe("Software System"), nil, v.external, v.description}
+	}
+	return summary{name: el.ID()}
+}
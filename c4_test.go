@@ -0,0 +1,27 @@
+package c4
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSummarizeShape(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		el   Element
+		want string
+	}{
+		{MustNewComponent(ctx, "c", ComponentArgs{Name: "C"}), "Component"},
+		{MustNewComponent(ctx, "c", ComponentArgs{Name: "C", Shape: ShapeDatabase}), "Component (Database)"},
+		{MustNewComponent(ctx, "c", ComponentArgs{Name: "C", Shape: ShapeQueue}), "Component (Queue)"},
+		{MustNewSystem(ctx, "s", SystemArgs{Name: "S"}), "Software System"},
+		{MustNewSystem(ctx, "s", SystemArgs{Name: "S", Shape: ShapeDatabase}), "Software System (Database)"},
+		{MustNewSystem(ctx, "s", SystemArgs{Name: "S", Shape: ShapeQueue}), "Software System (Queue)"},
+		{MustNewSystem(ctx, "s", SystemArgs{Name: "S", Shape: ShapeQueue}).Boundary(), "Software System (Queue)"},
+	}
+	for _, tt := range tests {
+		if got := summarize(tt.el).kind; got != tt.want {
+			t.Errorf("summarize(%s %T) got kind %q, want %q", tt.el.ID(), tt.el, got, tt.want)
+		}
+	}
+}
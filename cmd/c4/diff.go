@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/haleyrc/c4"
+	"github.com/haleyrc/c4/model"
+)
+
+type diffChange struct {
+	ID     string        `json:"id"`
+	Type   c4.ChangeType `json:"type"`
+	Fields []diffField   `json:"fields,omitempty"`
+}
+
+type diffField struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+func runDiff(ctx context.Context, c *cli, args []string) int {
+	fs := c.flags("diff", "<old model> <new model>")
+	rf := &renderFlags{imageFlags: addImageFlags(fs)}
+	fs.StringVar(&rf.format, "format", "", "Render a diagram of the changes in the given format, one of "+strings.Join(formatNames(), ", ")+", instead of listing them")
+	key := fs.String("view", "", "The key of a view to compare, instead of every element and relation")
+	out := fs.String("o", "", "Write a rendered diagram to the named file instead of standard output")
+	if status, ok := c.parse(fs, args, 2); !ok {
+		return status
+	}
+	var f format
+	if rf.format != "" {
+		var ok bool
+		if f, ok = rf.lookup(c); !ok {
+			return exitUsage
+		}
+	}
+
+	oldName, newName := fs.Arg(0), fs.Arg(1)
+	oldModel, status := c.load(ctx, oldName)
+	if oldModel == nil {
+		return status
+	}
+	newModel, status := c.load(ctx, newName)
+	if newModel == nil {
+		return status
+	}
+
+	var (
+		changes *c4.Changes
+		err     error
+	)
+	if *key == "" {
+		changes, err = model.Diff(ctx, oldModel, newModel)
+		if err != nil {
+			err = &model.Error{Message: err.Error()}
+		}
+	} else {
+		changes, err = diffView(ctx, oldName, oldModel, newName, newModel, *key)
+	}
+	if err != nil {
+		return c.fail(err)
+	}
+
+	if rf.format != "" {
+		d, err := changes.Diagram(ctx)
+		if err != nil {
+			return c.fail(&model.Error{File: newName, ID: *key, Message: err.Error()})
+		}
+		outputs, err := renderDiagrams(ctx, newName, []*c4.Diagram{d}, []string{*key}, f, rf)
+		if err != nil {
+			return c.fail(err)
+		}
+		if *out == "" {
+			if _, err := c.stdout.Write(outputs[0]); err != nil {
+				return c.fail(err)
+			}
+			return exitOK
+		}
+		if err := os.WriteFile(*out, outputs[0], 0o644); err != nil {
+			return c.fail(err)
+		}
+		return exitOK
+	}
+
+	var els, rels []diffChange
+	for _, change := range changes.Elements {
+		els = append(els, diffChange{ID: change.ID, Type: change.Type, Fields: diffFields(change.Fields)})
+	}
+	for _, change := range changes.Relations {
+		rels = append(rels, diffChange{ID: change.ID, Type: change.Type, Fields: diffFields(change.Fields)})
+	}
+
+	if c.json {
+		return c.writeJSON(struct {
+			Elements  []diffChange `json:"elements"`
+			Relations []diffChange `json:"relations"`
+		}{els, rels})
+	}
+
+	write := func(kind string, changes []diffChange) {
+		for _, change := range changes {
+			fmt.Fprintf(c.stdout, "%-8s %-8s %s\n", change.Type, kind, change.ID)
+			for _, field := range change.Fields {
+				fmt.Fprintf(c.stdout, "    %s: %q -> %q\n", field.Field, field.Old, field.New)
+			}
+		}
+	}
+	write("element", els)
+	write("relation", rels)
+	return exitOK
+}
+
+// diffView compares the view with the given key in each model.
+func diffView(ctx context.Context, oldName string, oldModel *model.Model, newName string, newModel *model.Model, key string) (*c4.Changes, error) {
+	od, err := oldModel.Diagram(ctx, key)
+	if err != nil {
+		return nil, &model.Error{File: oldName, ID: key, Message: err.Error()}
+	}
+	nd, err := newModel.Diagram(ctx, key)
+	if err != nil {
+		return nil, &model.Error{File: newName, ID: key, Message: err.Error()}
+	}
+	changes, err := c4.Diff(ctx, od, nd)
+	if err != nil {
+		return nil, &model.Error{ID: key, Message: err.Error()}
+	}
+	return changes, nil
+}
+
+func diffFields(fields []c4.FieldChange) []diffField {
+	var out []diffField
+	for _, f := range fields {
+		out = append(out, diffField{Field: f.Field, Old: f.Old, New: f.New})
+	}
+	return out
+}
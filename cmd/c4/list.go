@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/haleyrc/c4"
+	"github.com/haleyrc/c4/model"
+)
+
+type listElement struct {
+	ID     string  `json:"id"`
+	Kind   c4.Kind `json:"kind"`
+	Name   string  `json:"name"`
+	Parent string  `json:"parent,omitempty"`
+
+	depth int
+}
+
+type listView struct {
+	Key   string `json:"key"`
+	Title string `json:"title"`
+}
+
+// boundaryKinds maps the kinds of boundaries to the kinds of the elements
+// they are created from, which is how they are declared in a model.
+var boundaryKinds = map[c4.Kind]c4.Kind{
+	c4.KindContainerBoundary: c4.KindContainer,
+	c4.KindDatabaseBoundary:  c4.KindDatabase,
+	c4.KindSystemBoundary:    c4.KindSystem,
+}
+
+func runList(ctx context.Context, c *cli, args []string) int {
+	fs := c.flags("list", "<model>")
+	if status, ok := c.parse(fs, args, 1); !ok {
+		return status
+	}
+
+	m, status := c.load(ctx, fs.Arg(0))
+	if m == nil {
+		return status
+	}
+
+	d, err := m.All(ctx)
+	if err != nil {
+		return c.fail(&model.Error{File: fs.Arg(0), Message: err.Error()})
+	}
+	var els []listElement
+	listElements(&els, d.Elements(), "", 0)
+
+	var views []listView
+	for _, v := range m.Views() {
+		views = append(views, listView{Key: v.Key, Title: v.Title})
+	}
+
+	if c.json {
+		return c.writeJSON(struct {
+			Elements []listElement `json:"elements"`
+			Views    []listView    `json:"views"`
+		}{els, views})
+	}
+
+	tw := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tKIND\tNAME")
+	for _, el := range els {
+		fmt.Fprintf(tw, "%s%s\t%s\t%s\n", strings.Repeat("  ", el.depth), el.ID, el.Kind, el.Name)
+	}
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "VIEW\tTITLE")
+	for _, v := range views {
+		fmt.Fprintf(tw, "%s\t%s\n", v.Key, v.Title)
+	}
+	if err := tw.Flush(); err != nil {
+		return c.fail(err)
+	}
+	return exitOK
+}
+
+func listElements(out *[]listElement, els []c4.Element, parent string, depth int) {
+	for _, el := range els {
+		kind := c4.KindOf(el)
+		if k, ok := boundaryKinds[kind]; ok {
+			kind = k
+		}
+
+		var name string
+		switch v := el.(type) {
+		case *c4.Instance:
+			name = elementName(v.Of())
+		default:
+			name = elementName(v)
+		}
+
+		*out = append(*out, listElement{ID: el.ID(), Kind: kind, Name: name, Parent: parent, depth: depth})
+
+		if b, ok := el.(interface{ Elements() []c4.Element }); ok {
+			listElements(out, b.Elements(), el.ID(), depth+1)
+		}
+	}
+}
+
+func elementName(el c4.Element) string {
+	if n, ok := el.(interface{ Name() string }); ok {
+		return n.Name()
+	}
+	return ""
+}
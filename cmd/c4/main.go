@@ -0,0 +1,207 @@
+// Command c4 renders diagrams from c4 model files.
+//
+// Usage:
+//
+//	c4 <command> [flags] <model>
+//
+// The commands are:
+//
+//	render    render a single view as text or an image
+//	export    render every view to a directory
+//	show      draw views in the terminal
+//	diff      compare two versions of a model
+//	validate  check models for errors
+//	list      list the elements and views in a model
+//	serve     preview every view in a browser as the model changes
+//	site      generate a static HTML site documenting the model
+//
+// Models are YAML or JSON files in the format described by the model package.
+// Run "c4 <command> -h" for the flags accepted by each command.
+//
+// Views can be rendered as PlantUML, Mermaid or DOT text, as a Markdown report
+// describing their elements and relations, as a drawing made of box-drawing
+// characters, or as PNG, SVG or PDF images using a local installation of
+// PlantUML. The -plantuml flag gives the plantuml executable or the path to
+// plantuml.jar, while the -server and -kroki flags render images using a
+// PlantUML server or Kroki service instead.
+//
+// The command exits with status 0 on success, 1 if a model is invalid or
+// cannot be rendered, 2 if the command line is invalid and 3 if a file cannot
+// be read or written. When the -json flag is given, errors are written to
+// standard error as a JSON object of the form:
+//
+//	{"errors": [{"file": "model.yaml", "line": 12, "id": "api", "message": "unknown element"}]}
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/haleyrc/c4/model"
+)
+
+const (
+	exitOK      = 0
+	exitInvalid = 1
+	exitUsage   = 2
+	exitFailure = 3
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, cli *cli, args []string) int
+}
+
+var commands = []command{
+	{name: "render", summary: "render a single view as text or an image", run: runRender},
+	{name: "export", summary: "render every view to a directory", run: runExport},
+	{name: "show", summary: "draw views in the terminal", run: runShow},
+	{name: "diff", summary: "compare two versions of a model", run: runDiff},
+	{name: "validate", summary: "check models for errors", run: runValidate},
+	{name: "list", summary: "list the elements and views in a model", run: runList},
+	{name: "serve", summary: "preview every view in a browser as the model changes", run: runServe},
+	{name: "site", summary: "generate a static HTML site documenting the model", run: runSite},
+}
+
+func main() {
+	ctx := context.Background()
+	c := &cli{stdout: os.Stdout, stderr: os.Stderr}
+	os.Exit(c.main(ctx, os.Args[1:]))
+}
+
+// cli holds the output streams and the error format shared by commands.
+type cli struct {
+	stdout io.Writer
+	stderr io.Writer
+	json   bool
+}
+
+func (c *cli) main(ctx context.Context, args []string) int {
+	if len(args) == 0 {
+		c.usage()
+		return exitUsage
+	}
+
+	name := args[0]
+	if name == "help" || name == "-h" || name == "-help" || name == "--help" {
+		c.usage()
+		return exitOK
+	}
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd.run(ctx, c, args[1:])
+		}
+	}
+
+	fmt.Fprintf(c.stderr, "c4: unknown command %q\n", name)
+	c.usage()
+	return exitUsage
+}
+
+func (c *cli) usage() {
+	fmt.Fprintln(c.stderr, "Usage: c4 <command> [flags] <model>")
+	fmt.Fprintln(c.stderr)
+	fmt.Fprintln(c.stderr, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(c.stderr, "  %-9s %s\n", cmd.name, cmd.summary)
+	}
+}
+
+// flags returns a flag set for the named command with the flags shared by
+// every command already defined.
+func (c *cli) flags(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.BoolVar(&c.json, "json", false, "Write errors and output as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(c.stderr, "Usage: c4 %s [flags] %s\n\nFlags:\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parses the command line for a command that accepts exactly n
+// arguments, or at least one argument if n is negative.
+func (c *cli) parse(fs *flag.FlagSet, args []string, n int) (int, bool) {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK, false
+		}
+		return exitUsage, false
+	}
+	if (n < 0 && fs.NArg() == 0) || (n >= 0 && fs.NArg() != n) {
+		fs.Usage()
+		return exitUsage, false
+	}
+	return exitOK, true
+}
+
+// load reads the named model, reporting any errors.
+func (c *cli) load(ctx context.Context, name string) (*model.Model, int) {
+	m, err := model.LoadFile(ctx, name)
+	if err != nil {
+		return nil, c.fail(err)
+	}
+	return m, exitOK
+}
+
+// fail reports err and returns the corresponding exit status. Model errors
+// indicate an invalid model, while anything else is treated as a failure to
+// read or write files.
+func (c *cli) fail(err error) int {
+	var errs model.Errors
+	if errors.As(err, &errs) {
+		c.report(errs)
+		return exitInvalid
+	}
+	var e *model.Error
+	if errors.As(err, &e) {
+		c.report(model.Errors{e})
+		return exitInvalid
+	}
+	c.report(model.Errors{{Message: err.Error()}})
+	return exitFailure
+}
+
+func (c *cli) report(errs model.Errors) {
+	if c.json {
+		if errs == nil {
+			errs = model.Errors{}
+		}
+		enc := json.NewEncoder(c.stderr)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(struct {
+			Errors model.Errors `json:"errors"`
+		}{errs})
+		return
+	}
+	for _, err := range errs {
+		fmt.Fprintf(c.stderr, "c4: %s\n", err)
+	}
+}
+
+// writeJSON writes v to standard output as indented JSON.
+func (c *cli) writeJSON(v interface{}) int {
+	enc := json.NewEncoder(c.stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return c.fail(err)
+	}
+	return exitOK
+}
+
+func formatNames() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/haleyrc/c4"
+	"github.com/haleyrc/c4/model"
+	"github.com/haleyrc/c4/render"
+)
+
+// format is an output format supported by the render and export commands.
+type format struct {
+	// The extension used for exported files, including the leading dot.
+	ext string
+
+	// The method of the diagram that renders a text format.
+	text func(*c4.Diagram, context.Context, io.Writer) error
+
+	// The format of images rendered using PlantUML.
+	image render.Format
+}
+
+var formats = map[string]format{
+	"plantuml": {ext: ".puml", text: (*c4.Diagram).PlantUML},
+	"mermaid":  {ext: ".mmd", text: (*c4.Diagram).Mermaid},
+	"dot":      {ext: ".dot", text: (*c4.Diagram).DOT},
+	"markdown": {ext: ".md", text: (*c4.Diagram).Markdown},
+	"text":     {ext: ".txt", text: (*c4.Diagram).Text},
+	"png":      {ext: render.FormatPNG.Ext(), image: render.FormatPNG},
+	"svg":      {ext: render.FormatSVG.Ext(), image: render.FormatSVG},
+	"pdf":      {ext: render.FormatPDF.Ext(), image: render.FormatPDF},
+}
+
+// renderFlags are the flags shared by the commands that render views.
+type renderFlags struct {
+	format string
+	*imageFlags
+}
+
+func addRenderFlags(fs *flag.FlagSet) *renderFlags {
+	rf := &renderFlags{imageFlags: addImageFlags(fs)}
+	fs.StringVar(&rf.format, "format", "plantuml", "The output format, one of "+strings.Join(formatNames(), ", "))
+	return rf
+}
+
+// imageFlags select how images are rendered.
+type imageFlags struct {
+	plantuml string
+	server   string
+	kroki    string
+}
+
+func addImageFlags(fs *flag.FlagSet) *imageFlags {
+	f := &imageFlags{}
+	fs.StringVar(&f.plantuml, "plantuml", render.DefaultCommand, "The plantuml executable or the path to plantuml.jar, used to render images")
+	fs.StringVar(&f.server, "server", "", "The URL of a PlantUML server used to render images instead of a local PlantUML")
+	fs.StringVar(&f.kroki, "kroki", "", "The URL of a Kroki service used to render images instead of a local PlantUML")
+	return f
+}
+
+func (rf *renderFlags) lookup(c *cli) (format, bool) {
+	f, ok := formats[rf.format]
+	if !ok {
+		fmt.Fprintf(c.stderr, "c4: unknown format %q, expected one of %s\n", rf.format, strings.Join(formatNames(), ", "))
+	}
+	return f, ok
+}
+
+func runRender(ctx context.Context, c *cli, args []string) int {
+	fs := c.flags("render", "<model>")
+	rf := addRenderFlags(fs)
+	key := fs.String("view", "", "The key of the view to render, required if the model has more than one view")
+	out := fs.String("o", "", "Write the output to the named file instead of standard output")
+	if status, ok := c.parse(fs, args, 1); !ok {
+		return status
+	}
+	f, ok := rf.lookup(c)
+	if !ok {
+		return exitUsage
+	}
+
+	m, status := c.load(ctx, fs.Arg(0))
+	if m == nil {
+		return status
+	}
+
+	views := m.Views()
+	keys := make([]string, 0, len(views))
+	for _, v := range views {
+		keys = append(keys, v.Key)
+	}
+	switch {
+	case *key == "" && len(views) > 1:
+		fmt.Fprintf(c.stderr, "c4: the model has %d views, use -view to choose one of %s\n", len(views), strings.Join(keys, ", "))
+		return exitUsage
+	case *key == "":
+		*key = views[0].Key
+	case !contains(keys, *key):
+		fmt.Fprintf(c.stderr, "c4: unknown view %q, expected one of %s\n", *key, strings.Join(keys, ", "))
+		return exitUsage
+	}
+
+	outputs, err := renderViews(ctx, fs.Arg(0), m, f, rf, *key)
+	if err != nil {
+		return c.fail(err)
+	}
+
+	if *out == "" {
+		if _, err := c.stdout.Write(outputs[0]); err != nil {
+			return c.fail(err)
+		}
+		return exitOK
+	}
+	if err := os.WriteFile(*out, outputs[0], 0o644); err != nil {
+		return c.fail(err)
+	}
+	return exitOK
+}
+
+func runExport(ctx context.Context, c *cli, args []string) int {
+	fs := c.flags("export", "<model>")
+	rf := addRenderFlags(fs)
+	dir := fs.String("dir", ".", "The directory to write the diagrams to")
+	if status, ok := c.parse(fs, args, 1); !ok {
+		return status
+	}
+	f, ok := rf.lookup(c)
+	if !ok {
+		return exitUsage
+	}
+
+	m, status := c.load(ctx, fs.Arg(0))
+	if m == nil {
+		return status
+	}
+
+	// Every view is rendered before anything is written so that an invalid
+	// view doesn't leave a partial export behind.
+	views := m.Views()
+	keys := make([]string, 0, len(views))
+	for _, v := range views {
+		keys = append(keys, v.Key)
+	}
+	outputs, err := renderViews(ctx, fs.Arg(0), m, f, rf, keys...)
+	if err != nil {
+		return c.fail(err)
+	}
+
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		return c.fail(err)
+	}
+	var written []string
+	for i, v := range views {
+		name := filepath.Join(*dir, v.Key+f.ext)
+		if err := os.WriteFile(name, outputs[i], 0o644); err != nil {
+			return c.fail(err)
+		}
+		written = append(written, name)
+	}
+
+	if c.json {
+		return c.writeJSON(struct {
+			Files []string `json:"files"`
+		}{written})
+	}
+	for _, name := range written {
+		fmt.Fprintln(c.stdout, name)
+	}
+	return exitOK
+}
+
+// renderViews renders the views with the given keys from the named model
+// file. Errors produced while constructing or rendering the diagrams are
+// reported against the view.
+func renderViews(ctx context.Context, name string, m *model.Model, f format, rf *renderFlags, keys ...string) ([][]byte, error) {
+	ds := make([]*c4.Diagram, len(keys))
+	for i, key := range keys {
+		d, err := m.Diagram(ctx, key)
+		if err != nil {
+			return nil, &model.Error{File: name, ID: key, Message: err.Error()}
+		}
+		ds[i] = d
+	}
+	return renderDiagrams(ctx, name, ds, keys, f, rf)
+}
+
+// renderDiagrams renders diagrams in the given format. Errors are reported
+// against the named file and the key of the diagram responsible.
+func renderDiagrams(ctx context.Context, name string, ds []*c4.Diagram, keys []string, f format, rf *renderFlags) ([][]byte, error) {
+	if f.text != nil {
+		outputs := make([][]byte, len(ds))
+		for i, d := range ds {
+			var buff bytes.Buffer
+			if err := f.text(d, ctx, &buff); err != nil {
+				return nil, &model.Error{File: name, ID: keys[i], Message: err.Error()}
+			}
+			outputs[i] = buff.Bytes()
+		}
+		return outputs, nil
+	}
+
+	r, err := rf.renderer(ctx, f.image)
+	if err != nil {
+		return nil, err
+	}
+	images, err := render.All(ctx, r, ds...)
+	if err != nil {
+		return nil, viewErrors(name, ds, keys, err)
+	}
+	return images, nil
+}
+
+// viewErrors converts any diagrams rejected by PlantUML into errors against
+// the views of the named model file. Other errors are returned as-is.
+func viewErrors(name string, ds []*c4.Diagram, keys []string, err error) error {
+	var syntaxErrs render.SyntaxErrors
+	if !errors.As(err, &syntaxErrs) {
+		return err
+	}
+	errs := make(model.Errors, 0, len(syntaxErrs))
+	for _, se := range syntaxErrs {
+		errs = append(errs, syntaxError(name, ds, keys, se))
+	}
+	return errs
+}
+
+// renderer returns the renderer selected by the flags for images of the given
+// format.
+func (f *imageFlags) renderer(ctx context.Context, imageFormat render.Format) (render.Renderer, error) {
+	switch {
+	case f.server != "":
+		return render.NewServer(ctx, render.ServerArgs{URL: f.server, Format: imageFormat})
+	case f.kroki != "":
+		return render.NewKroki(ctx, render.KrokiArgs{URL: f.kroki, Format: imageFormat})
+	case strings.HasSuffix(f.plantuml, ".jar"):
+		return render.NewPlantUML(ctx, render.PlantUMLArgs{Jar: f.plantuml, Format: imageFormat})
+	default:
+		return render.NewPlantUML(ctx, render.PlantUMLArgs{Command: f.plantuml, Format: imageFormat})
+	}
+}
+
+// syntaxError reports a problem found by PlantUML against the element on the
+// offending line, or the view if there isn't one.
+func syntaxError(name string, ds []*c4.Diagram, keys []string, se *render.SyntaxError) *model.Error {
+	key := ""
+	for i, d := range ds {
+		if d == se.Diagram {
+			key = keys[i]
+		}
+	}
+	e := &model.Error{File: name, ID: se.ID, Message: fmt.Sprintf("view %s: %s (PlantUML line %d)", key, se.Message, se.Line)}
+	if e.ID == "" {
+		e.ID = key
+		e.Message = fmt.Sprintf("%s (PlantUML line %d)", se.Message, se.Line)
+	}
+	return e
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+
+	"github.com/haleyrc/c4/preview"
+	"github.com/haleyrc/c4/render"
+)
+
+func runServe(ctx context.Context, c *cli, args []string) int {
+	fs := c.flags("serve", "<model>")
+	imf := addImageFlags(fs)
+	addr := fs.String("addr", "localhost:8080", "The address to listen on")
+	interval := fs.Duration("interval", preview.DefaultInterval, "How often to check the model for changes")
+	if status, ok := c.parse(fs, args, 1); !ok {
+		return status
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	r, err := imf.renderer(ctx, render.FormatSVG)
+	if err != nil {
+		return c.fail(err)
+	}
+	h, err := preview.NewHandler(ctx, preview.HandlerArgs{
+		Model:    fs.Arg(0),
+		Renderer: r,
+		Interval: *interval,
+	})
+	if err != nil {
+		return c.fail(err)
+	}
+
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return c.fail(err)
+	}
+	fmt.Fprintf(c.stderr, "c4: serving %s at http://%s\n", fs.Arg(0), ln.Addr())
+
+	srv := &http.Server{Handler: h}
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+	go func() { _ = h.Watch(ctx) }()
+
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return c.fail(err)
+	}
+	return exitOK
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/haleyrc/c4"
+	"github.com/haleyrc/c4/model"
+)
+
+func runShow(ctx context.Context, c *cli, args []string) int {
+	fs := c.flags("show", "<model>")
+	key := fs.String("view", "", "The key of the view to show, or every view if not given")
+	ascii := fs.Bool("ascii", false, "Draw using only ASCII characters")
+	if status, ok := c.parse(fs, args, 1); !ok {
+		return status
+	}
+
+	m, status := c.load(ctx, fs.Arg(0))
+	if m == nil {
+		return status
+	}
+
+	keys := make([]string, 0, len(m.Views()))
+	for _, v := range m.Views() {
+		keys = append(keys, v.Key)
+	}
+	if *key != "" {
+		if !contains(keys, *key) {
+			fmt.Fprintf(c.stderr, "c4: unknown view %q, expected one of %s\n", *key, strings.Join(keys, ", "))
+			return exitUsage
+		}
+		keys = []string{*key}
+	}
+
+	var opts []c4.DiagramOption
+	if *ascii {
+		opts = append(opts, c4.WithASCII())
+	}
+
+	// Views are drawn before anything is written so that an invalid view
+	// doesn't leave partial output behind.
+	var buff bytes.Buffer
+	for i, key := range keys {
+		if i > 0 {
+			fmt.Fprintln(&buff)
+		}
+		d, err := m.Diagram(ctx, key, opts...)
+		if err == nil {
+			err = d.Text(ctx, &buff)
+		}
+		if err != nil {
+			return c.fail(&model.Error{File: fs.Arg(0), ID: key, Message: err.Error()})
+		}
+	}
+
+	if _, err := c.stdout.Write(buff.Bytes()); err != nil {
+		return c.fail(err)
+	}
+	return exitOK
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/haleyrc/c4"
+	"github.com/haleyrc/c4/model"
+	"github.com/haleyrc/c4/render"
+	"github.com/haleyrc/c4/site"
+)
+
+func runSite(ctx context.Context, c *cli, args []string) int {
+	fs := c.flags("site", "<model>")
+	imf := addImageFlags(fs)
+	dir := fs.String("dir", "site", "The directory to write the site to")
+	if status, ok := c.parse(fs, args, 1); !ok {
+		return status
+	}
+
+	m, status := c.load(ctx, fs.Arg(0))
+	if m == nil {
+		return status
+	}
+
+	views := m.Views()
+	ds := make([]*c4.Diagram, 0, len(views))
+	keys := make([]string, 0, len(views))
+	for _, v := range views {
+		d, err := m.Diagram(ctx, v.Key)
+		if err != nil {
+			return c.fail(&model.Error{File: fs.Arg(0), ID: v.Key, Message: err.Error()})
+		}
+		ds = append(ds, d)
+		keys = append(keys, v.Key)
+	}
+
+	r, err := imf.renderer(ctx, render.FormatSVG)
+	if err != nil {
+		return c.fail(err)
+	}
+	err = site.Generate(ctx, *dir, site.GenerateArgs{
+		Title:    m.Title(),
+		Diagrams: ds,
+		Renderer: r,
+	})
+	if err != nil {
+		return c.fail(viewErrors(fs.Arg(0), ds, keys, err))
+	}
+
+	fmt.Fprintln(c.stdout, *dir)
+	return exitOK
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/haleyrc/c4/model"
+)
+
+func runValidate(ctx context.Context, c *cli, args []string) int {
+	fs := c.flags("validate", "<model>...")
+	if status, ok := c.parse(fs, args, -1); !ok {
+		return status
+	}
+
+	var (
+		all    model.Errors
+		status = exitOK
+	)
+	for _, name := range fs.Args() {
+		errs, err := validate(ctx, name)
+		if err != nil {
+			all = append(all, &model.Error{Message: err.Error()})
+			status = exitFailure
+			continue
+		}
+		if len(errs) > 0 {
+			all = append(all, errs...)
+			if status == exitOK {
+				status = exitInvalid
+			}
+		}
+	}
+
+	if status != exitOK {
+		c.report(all)
+	}
+	return status
+}
+
+// validate loads the named model and checks that every view can be
+// constructed. Problems with the model are returned as Errors, while the
+// error is only set if the file can't be read.
+func validate(ctx context.Context, name string) (model.Errors, error) {
+	m, err := model.LoadFile(ctx, name)
+	if err != nil {
+		var errs model.Errors
+		if errors.As(err, &errs) {
+			return errs, nil
+		}
+		return nil, err
+	}
+
+	var errs model.Errors
+	for _, v := range m.Views() {
+		d, err := m.Diagram(ctx, v.Key)
+		if err == nil {
+			err = d.Validate(ctx)
+		}
+		if err != nil {
+			errs = append(errs, &model.Error{File: name, ID: v.Key, Message: err.Error()})
+		}
+	}
+	return errs, nil
+}
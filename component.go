@@ -17,6 +17,22 @@ type ComponentArgs struct {
 
 	// Enables alternate styling reserved for external elements.
 	External bool
+
+	// An optional shape for the component e.g. ShapeDatabase for an in-process
+	// cache.
+	Shape Shape
+
+	// An optional sprite to display on the component e.g.
+	// "font-awesome/cogs". See SpriteLibrary for the available libraries.
+	Sprite string
+
+	// An optional list of properties describing the component e.g. an owner or
+	// SLA. These are displayed as a table within the component.
+	Properties []Property
+
+	// An optional list of tags classifying the component e.g. "pci". Diagrams can
+	// be filtered by tag using Diagram.Filter.
+	Tags []string
 }
 
 // MustNewComponent is the same as NewComponent, but panics on any error.
@@ -36,6 +52,10 @@ func NewComponent(ctx context.Context, id string, args ComponentArgs) (*Componen
 		description:  args.Description,
 		technologies: args.Technologies,
 		external:     args.External,
+		sprite:       args.Sprite,
+		properties:   args.Properties,
+		tags:         args.Tags,
+		shape:        args.Shape,
 	}
 	return c, nil
 }
@@ -49,7 +69,35 @@ type Component struct {
 	description  string
 	technologies []string
 	external     bool
+	sprite       string
+	tags         []string
+	properties   []Property
+	shape        Shape
 }
 
 // ID satisfies the Element interface.
 func (c *Component) ID() string { return c.id }
+
+// Name returns the human-readable name of the component.
+func (c *Component) Name() string { return c.name }
+
+// Description returns the description of the component.
+func (c *Component) Description() string { return c.description }
+
+// Technologies returns the technologies describing the component.
+func (c *Component) Technologies() []string { return c.technologies }
+
+// External reports whether the component is styled as an external element.
+func (c *Component) External() bool { return c.external }
+
+// Shape returns the shape of the component.
+func (c *Component) Shape() Shape { return c.shape }
+
+// Sprite returns the sprite displayed on the component, if any.
+func (c *Component) Sprite() string { return c.sprite }
+
+// Properties returns the properties describing the component.
+func (c *Component) Properties() []Property { return c.properties }
+
+// Tags returns the tags classifying the component.
+func (c *Component) Tags() []string { return c.tags }
@@ -0,0 +1,278 @@
+// Package compose builds c4 container diagrams from docker-compose files.
+//
+// Each service in the compose file becomes a container, database or queue
+// depending on its image. Well-known images such as postgres, redis and kafka
+// are recognized automatically and the mapping can be extended with the
+// Images field of ImportArgs. Relations are derived from depends_on and links,
+// limited to services that share a network, and from services to the databases
+// and queues they share a network with. The ports exposed by the destination
+// are used as the relation technologies.
+//
+//	d, err := compose.ImportFile(ctx, "docker-compose.yml", compose.ImportArgs{
+//		System: internetBankingSystem,
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	d.PlantUML(ctx, os.Stdout)
+package compose
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/haleyrc/c4"
+	"github.com/haleyrc/c4/internal/alias"
+)
+
+// Kind represents the type of c4 element a service is converted to.
+type Kind string
+
+const (
+	KindContainer Kind = "container"
+	KindDatabase  Kind = "database"
+	KindQueue     Kind = "queue"
+)
+
+// DefaultImages maps well-known image repositories to the kind of element they
+// represent. An image matches if its repository, ignoring any registry, tag and
+// digest, equals the key e.g. "mcr.microsoft.com/mssql/server:2022-latest"
+// matches "mssql/server". Otherwise, it matches if the last segment of its
+// repository equals the key e.g. "bitnami/kafka:3.6" matches "kafka". Images
+// such as mongo-express or kafka-ui, which only share a prefix with a key, are
+// containers.
+var DefaultImages = map[string]Kind{
+	"cassandra":          KindDatabase,
+	"clickhouse":         KindDatabase,
+	"clickhouse-server":  KindDatabase,
+	"cockroach":          KindDatabase,
+	"couchbase":          KindDatabase,
+	"couchdb":            KindDatabase,
+	"dynamodb-local":     KindDatabase,
+	"elasticsearch":      KindDatabase,
+	"influxdb":           KindDatabase,
+	"mariadb":            KindDatabase,
+	"memcached":          KindDatabase,
+	"mongo":              KindDatabase,
+	"mongodb":            KindDatabase,
+	"mssql/server":       KindDatabase,
+	"mysql":              KindDatabase,
+	"mysql-server":       KindDatabase,
+	"neo4j":              KindDatabase,
+	"opensearch":         KindDatabase,
+	"postgis":            KindDatabase,
+	"postgres":           KindDatabase,
+	"postgresql":         KindDatabase,
+	"redis":              KindDatabase,
+	"redis-stack":        KindDatabase,
+	"redis-stack-server": KindDatabase,
+	"timescaledb":        KindDatabase,
+	"valkey":             KindDatabase,
+
+	"activemq":         KindQueue,
+	"activemq-artemis": KindQueue,
+	"cp-kafka":         KindQueue,
+	"kafka":            KindQueue,
+	"nats":             KindQueue,
+	"nats-streaming":   KindQueue,
+	"pulsar":           KindQueue,
+	"rabbitmq":         KindQueue,
+	"redpanda":         KindQueue,
+}
+
+// ImportArgs describes the parameters available for importing a compose file.
+type ImportArgs struct {
+	// The title of the resultant diagram. Defaults to the name of the compose
+	// project or "Containers" if the file doesn't declare one.
+	Title string
+
+	// An optional system whose boundary the imported elements are grouped
+	// within.
+	System *c4.System
+
+	// Additional image mappings that take precedence over DefaultImages.
+	Images map[string]Kind
+
+	// Options used when constructing the resultant diagram.
+	Options []c4.DiagramOption
+}
+
+// ImportFile is the same as Import, but reads the named compose file.
+func ImportFile(ctx context.Context, name string, args ImportArgs) (*c4.Diagram, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("compose: import: %w", err)
+	}
+	defer f.Close()
+
+	return Import(ctx, f, args)
+}
+
+// Import reads a compose file from r and converts it into a container diagram.
+func Import(ctx context.Context, r io.Reader, args ImportArgs) (*c4.Diagram, error) {
+	var file struct {
+		Name     string    `yaml:"name"`
+		Services yaml.Node `yaml:"services"`
+	}
+	if err := yaml.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("compose: import: %w", err)
+	}
+
+	svcs, err := decodeServices(&file.Services)
+	if err != nil {
+		return nil, fmt.Errorf("compose: import: %w", err)
+	}
+
+	title := args.Title
+	if title == "" {
+		title = file.Name
+	}
+	if title == "" {
+		title = "Containers"
+	}
+
+	d, err := c4.NewDiagram(ctx, title, args.Options...)
+	if err != nil {
+		return nil, err
+	}
+
+	var parent c4.Boundary
+	if args.System != nil {
+		parent = args.System.Boundary()
+		d.AddElement(ctx, parent)
+	}
+
+	elements := map[string]c4.Element{}
+	kinds := map[string]Kind{}
+	for _, svc := range svcs {
+		kinds[svc.name] = kindOf(svc.Image, args.Images)
+		el, err := newElement(ctx, svc, kinds[svc.name])
+		if err != nil {
+			return nil, err
+		}
+		elements[svc.name] = el
+		if parent != nil {
+			parent.AddElement(ctx, el)
+		} else {
+			d.AddElement(ctx, el)
+		}
+	}
+
+	byName := map[string]service{}
+	for _, svc := range svcs {
+		byName[svc.name] = svc
+	}
+
+	for _, svc := range svcs {
+		var dsts []service
+		related := map[string]bool{}
+		for _, dep := range svc.dependencies() {
+			dst, ok := byName[dep]
+			if !ok || related[dep] || !svc.sharesNetwork(dst) {
+				continue
+			}
+			dsts = append(dsts, dst)
+			related[dep] = true
+		}
+
+		// Services are assumed to use the databases and queues they share a
+		// network with, whether or not they depend on them explicitly.
+		if kinds[svc.name] == KindContainer {
+			for _, dst := range svcs {
+				if dst.name == svc.name || related[dst.name] || kinds[dst.name] == KindContainer || !svc.sharesNetwork(dst) {
+					continue
+				}
+				dsts = append(dsts, dst)
+				related[dst.name] = true
+			}
+		}
+
+		for _, dst := range dsts {
+			err := d.NewRelation(ctx, c4.RelationArgs{
+				Src:          elements[svc.name],
+				Dst:          elements[dst.name],
+				Description:  "Uses",
+				Technologies: dst.ports(),
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return d, nil
+}
+
+func newElement(ctx context.Context, svc service, kind Kind) (c4.Element, error) {
+	id := alias.Make(svc.name)
+	name := svc.name
+	description := svc.Labels["org.opencontainers.image.description"]
+
+	technologies := []string{"Docker"}
+	if svc.Image != "" {
+		technologies = []string{svc.Image}
+	}
+
+	switch kind {
+	case KindDatabase:
+		return c4.NewDatabase(ctx, id, c4.DatabaseArgs{
+			Name:         name,
+			Description:  description,
+			Technologies: technologies,
+		})
+	case KindQueue:
+		return c4.NewQueue(ctx, id, c4.QueueArgs{
+			Name:         name,
+			Description:  description,
+			Technologies: technologies,
+		})
+	default:
+		return c4.NewContainer(ctx, id, c4.ContainerArgs{
+			Name:         name,
+			Description:  description,
+			Technologies: technologies,
+		})
+	}
+}
+
+// kindOf returns the kind of element for an image using the user-supplied
+// mappings before falling back to DefaultImages.
+func kindOf(image string, images map[string]Kind) Kind {
+	repo := repository(image)
+	if repo == "" {
+		return KindContainer
+	}
+
+	for _, mapping := range []map[string]Kind{images, DefaultImages} {
+		if kind, ok := mapping[repo]; ok {
+			return kind
+		}
+		if kind, ok := mapping[path.Base(repo)]; ok {
+			return kind
+		}
+	}
+
+	return KindContainer
+}
+
+// repository returns the repository of an image without its registry, tag or
+// digest e.g. "ghcr.io/example/api:1.2" returns "example/api".
+func repository(image string) string {
+	repo, _, _ := strings.Cut(image, "@")
+	if i := strings.LastIndex(repo, ":"); i > strings.LastIndex(repo, "/") {
+		repo = repo[:i]
+	}
+
+	// As with docker, the first segment is a registry if it contains a dot
+	// or port, or is localhost.
+	if registry, rest, ok := strings.Cut(repo, "/"); ok && (strings.ContainsAny(registry, ".:") || registry == "localhost") {
+		repo = rest
+	}
+	return strings.TrimPrefix(repo, "library/")
+}
@@ -0,0 +1,141 @@
+package compose
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/haleyrc/c4"
+)
+
+func TestKindOf(t *testing.T) {
+	tests := []struct {
+		image string
+		want  Kind
+	}{
+		{"", KindContainer},
+		{"postgres", KindDatabase},
+		{"postgres:16-alpine", KindDatabase},
+		{"library/postgres:16", KindDatabase},
+		{"docker.io/library/redis:7", KindDatabase},
+		{"localhost:5000/mongo", KindDatabase},
+		{"bitnami/kafka:3.6", KindQueue},
+		{"confluentinc/cp-kafka:7.5.0", KindQueue},
+		{"mcr.microsoft.com/mssql/server:2022-latest", KindDatabase},
+		{"redis@sha256:0123456789abcdef", KindDatabase},
+		{"mongo-express", KindContainer},
+		{"rediscommander/redis-commander:latest", KindContainer},
+		{"provectuslabs/kafka-ui", KindContainer},
+		{"prometheuscommunity/postgres-exporter", KindContainer},
+		{"example/mssql", KindContainer},
+		{"nginx:1.25", KindContainer},
+	}
+	for _, tt := range tests {
+		if got := kindOf(tt.image, nil); got != tt.want {
+			t.Errorf("kindOf(%q) = %q, want %q", tt.image, got, tt.want)
+		}
+	}
+
+	images := map[string]Kind{"example/events": KindQueue, "postgres": KindContainer}
+	for image, want := range map[string]Kind{
+		"ghcr.io/example/events:1.0": KindQueue,
+		"postgres:16":                KindContainer,
+		"redis":                      KindDatabase,
+	} {
+		if got := kindOf(image, images); got != want {
+			t.Errorf("kindOf(%q) with %v = %q, want %q", image, images, got, want)
+		}
+	}
+}
+
+func TestImportFile(t *testing.T) {
+	ctx := context.Background()
+	d, err := ImportFile(ctx, filepath.Join("testdata", "docker-compose.yml"), ImportArgs{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.Title() != "Shop" {
+		t.Errorf("got title %q, want %q", d.Title(), "Shop")
+	}
+
+	var elements []string
+	for _, el := range d.Elements() {
+		elements = append(elements, el.ID()+" "+string(c4.KindOf(el)))
+	}
+	wantElements := []string{
+		"web container",
+		"api container",
+		"worker container",
+		"db database",
+		"cache database",
+		"events queue",
+		"reports database",
+		"kafka_ui container",
+		"redis_commander container",
+		"mongo_express container",
+		"postgres_exporter container",
+	}
+	if got, want := strings.Join(elements, "\n"), strings.Join(wantElements, "\n"); got != want {
+		t.Errorf("got elements:\n%s\nwant:\n%s", got, want)
+	}
+
+	var relations []string
+	for _, rel := range d.Relations() {
+		relations = append(relations, rel.Src.ID()+" -> "+rel.Dst.ID()+" "+strings.Join(rel.Technologies, ", "))
+	}
+	wantRelations := []string{
+		// Explicit dependencies come first, followed by the databases and
+		// queues on a shared network. The dependency of kafka-ui on events
+		// is dropped because they don't share a network.
+		"web -> api TCP/8000",
+		"api -> db TCP/5432",
+		"api -> cache TCP/6379",
+		"api -> events TCP/9092",
+		"worker -> db TCP/5432",
+		"worker -> cache TCP/6379",
+		"worker -> events TCP/9092",
+		"redis_commander -> db TCP/5432",
+		"redis_commander -> cache TCP/6379",
+		"redis_commander -> events TCP/9092",
+		"postgres_exporter -> db TCP/5432",
+		"postgres_exporter -> cache TCP/6379",
+		"postgres_exporter -> events TCP/9092",
+	}
+	if got, want := strings.Join(relations, "\n"), strings.Join(wantRelations, "\n"); got != want {
+		t.Errorf("got relations:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestImportSystem(t *testing.T) {
+	ctx := context.Background()
+	system := c4.MustNewSystem(ctx, "shop", c4.SystemArgs{Name: "Shop"})
+	src := `
+services:
+  app:
+    image: example/app
+  db:
+    image: mysql:8
+    expose: ["3306"]
+`
+	d, err := Import(ctx, strings.NewReader(src), ImportArgs{Title: "Containers", System: system})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	els := d.Elements()
+	if len(els) != 1 || els[0].ID() != "shop" {
+		t.Fatalf("got %d elements, want the system boundary", len(els))
+	}
+	b, ok := els[0].(interface{ Elements() []c4.Element })
+	if !ok || len(b.Elements()) != 2 {
+		t.Fatalf("got %T, want a boundary containing both services", els[0])
+	}
+
+	// Every service is on the default network when none are given.
+	rels := d.Relations()
+	if len(rels) != 1 || rels[0].Src.ID() != "app" || rels[0].Dst.ID() != "db" {
+		t.Errorf("got %d relations, want app -> db", len(rels))
+	}
+}
@@ -0,0 +1,165 @@
+package compose
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// service holds the subset of a compose service definition used to build
+// elements and relations. Several fields accept either a list or a mapping in
+// the compose specification, so they are decoded from raw nodes.
+type service struct {
+	name string
+
+	Image     string    `yaml:"image"`
+	DependsOn yaml.Node `yaml:"depends_on"`
+	Links     []string  `yaml:"links"`
+	Networks  yaml.Node `yaml:"networks"`
+	Ports     yaml.Node `yaml:"ports"`
+	Expose    []string  `yaml:"expose"`
+	Labels    labels    `yaml:"labels"`
+}
+
+// decodeServices decodes the services mapping, preserving the order in which
+// services are declared.
+func decodeServices(node *yaml.Node) ([]service, error) {
+	if node.Kind == 0 {
+		return nil, nil
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: services must be a mapping", node.Line)
+	}
+
+	var svcs []service
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		var svc service
+		if err := node.Content[i+1].Decode(&svc); err != nil {
+			return nil, err
+		}
+		svc.name = node.Content[i].Value
+		svcs = append(svcs, svc)
+	}
+	return svcs, nil
+}
+
+// dependencies returns the names of the services this service depends on,
+// either explicitly or via a legacy link.
+func (s service) dependencies() []string {
+	deps := keys(&s.DependsOn)
+	for _, link := range s.Links {
+		name, _, _ := strings.Cut(link, ":")
+		deps = append(deps, name)
+	}
+	return deps
+}
+
+// networks returns the names of the networks the service is attached to. If
+// none are given, the service is attached to the default network.
+func (s service) networks() []string {
+	networks := keys(&s.Networks)
+	if len(networks) == 0 {
+		networks = []string{"default"}
+	}
+	return networks
+}
+
+func (s service) sharesNetwork(other service) bool {
+	for _, a := range s.networks() {
+		for _, b := range other.networks() {
+			if a == b {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ports returns the ports the service listens on within the compose network
+// e.g. "TCP/5432". Published ports are described by their container side since
+// that is the port used by other services.
+func (s service) ports() []string {
+	var ports []string
+	seen := map[string]bool{}
+	add := func(port, protocol string) {
+		if port == "" {
+			return
+		}
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		p := strings.ToUpper(protocol) + "/" + port
+		if !seen[p] {
+			seen[p] = true
+			ports = append(ports, p)
+		}
+	}
+
+	if s.Ports.Kind == yaml.SequenceNode {
+		for _, n := range s.Ports.Content {
+			switch n.Kind {
+			case yaml.ScalarNode:
+				add(parsePort(n.Value))
+			case yaml.MappingNode:
+				var long struct {
+					Target   string `yaml:"target"`
+					Protocol string `yaml:"protocol"`
+				}
+				if err := n.Decode(&long); err == nil {
+					add(long.Target, long.Protocol)
+				}
+			}
+		}
+	}
+	for _, port := range s.Expose {
+		add(parsePort(port))
+	}
+
+	return ports
+}
+
+// parsePort returns the container port and protocol from a short port syntax
+// such as "127.0.0.1:8080:80/udp".
+func parsePort(spec string) (port, protocol string) {
+	spec, protocol, _ = strings.Cut(spec, "/")
+	if i := strings.LastIndex(spec, ":"); i >= 0 {
+		spec = spec[i+1:]
+	}
+	return spec, protocol
+}
+
+// keys returns the entries of a node that may be either a sequence of names or
+// a mapping keyed by name.
+func keys(node *yaml.Node) []string {
+	var names []string
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for _, n := range node.Content {
+			names = append(names, n.Value)
+		}
+	case yaml.MappingNode:
+		for i := 0; i < len(node.Content); i += 2 {
+			names = append(names, node.Content[i].Value)
+		}
+	}
+	return names
+}
+
+// labels decodes service labels given as either a mapping or a list of
+// "key=value" strings.
+type labels map[string]string
+
+func (l *labels) UnmarshalYAML(node *yaml.Node) error {
+	*l = labels{}
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for _, n := range node.Content {
+			k, v, _ := strings.Cut(n.Value, "=")
+			(*l)[k] = v
+		}
+	case yaml.MappingNode:
+		return node.Decode((*map[string]string)(l))
+	}
+	return nil
+}
@@ -17,6 +17,18 @@ type ContainerArgs struct {
 
 	// Enables alternate styling reserved for external elements.
 	External bool
+
+	// An optional sprite to display on the container e.g. "devicons/java". See
+	// SpriteLibrary for the available libraries.
+	Sprite string
+
+	// An optional list of properties describing the container e.g. an owner or
+	// SLA. These are displayed as a table within the container.
+	Properties []Property
+
+	// An optional list of tags classifying the container e.g. "pci". Diagrams can
+	// be filtered by tag using Diagram.Filter.
+	Tags []string
 }
 
 // MustNewContainer is the same as NewContainer, but panics on any error.
@@ -31,10 +43,14 @@ func MustNewContainer(ctx context.Context, id string, args ContainerArgs) *Conta
 // NewContainer constructs a container that can be used in a Diagram.
 func NewContainer(ctx context.Context, id string, args ContainerArgs) (*Container, error) {
 	c := &Container{
-		id:          id,
-		name:        args.Name,
-		description: args.Description,
-		external:    args.External,
+		id:           id,
+		name:         args.Name,
+		description:  args.Description,
+		technologies: args.Technologies,
+		external:     args.External,
+		sprite:       args.Sprite,
+		properties:   args.Properties,
+		tags:         args.Tags,
 	}
 	return c, nil
 }
@@ -51,6 +67,9 @@ type Container struct {
 	description  string
 	technologies []string
 	external     bool
+	sprite       string
+	tags         []string
+	properties   []Property
 }
 
 // Boundary returns a container boundary which can be used to group
@@ -62,6 +81,27 @@ func (c *Container) Boundary() Boundary {
 // ID satisfies the Element interface.
 func (c *Container) ID() string { return c.id }
 
+// Name returns the human-readable name of the container.
+func (c *Container) Name() string { return c.name }
+
+// Description returns the description of the container.
+func (c *Container) Description() string { return c.description }
+
+// Technologies returns the technologies describing the container.
+func (c *Container) Technologies() []string { return c.technologies }
+
+// External reports whether the container is styled as an external element.
+func (c *Container) External() bool { return c.external }
+
+// Sprite returns the sprite displayed on the container, if any.
+func (c *Container) Sprite() string { return c.sprite }
+
+// Properties returns the properties describing the container.
+func (c *Container) Properties() []Property { return c.properties }
+
+// Tags returns the tags classifying the container.
+func (c *Container) Tags() []string { return c.tags }
+
 type containerBoundary struct {
 	*Container
 	elements []Element
@@ -70,3 +110,5 @@ type containerBoundary struct {
 func (cb *containerBoundary) AddElement(ctx context.Context, el Element) {
 	cb.elements = append(cb.elements, el)
 }
+
+func (cb *containerBoundary) Elements() []Element { return cb.elements }
@@ -18,6 +18,18 @@ type DatabaseArgs struct {
 
 	// Enables alternate styling reserved for external elements.
 	External bool
+
+	// An optional sprite to display on the database e.g.
+	// "devicons/postgresql". See SpriteLibrary for the available libraries.
+	Sprite string
+
+	// An optional list of properties describing the database e.g. an owner or
+	// SLA. These are displayed as a table within the database.
+	Properties []Property
+
+	// An optional list of tags classifying the database e.g. "pci". Diagrams can
+	// be filtered by tag using Diagram.Filter.
+	Tags []string
 }
 
 // MustNewDatabase is the same as NewDatabase, but panics on any error.
@@ -32,10 +44,14 @@ func MustNewDatabase(ctx context.Context, id string, args DatabaseArgs) *Databas
 // NewDatabase constructs a database container that can be used in a Diagram.
 func NewDatabase(ctx context.Context, id string, args DatabaseArgs) (*Database, error) {
 	c := &Database{
-		id:          id,
-		name:        args.Name,
-		description: args.Description,
-		external:    args.External,
+		id:           id,
+		name:         args.Name,
+		description:  args.Description,
+		technologies: args.Technologies,
+		external:     args.External,
+		sprite:       args.Sprite,
+		properties:   args.Properties,
+		tags:         args.Tags,
 	}
 	return c, nil
 }
@@ -50,7 +66,48 @@ type Database struct {
 	description  string
 	technologies []string
 	external     bool
+	sprite       string
+	tags         []string
+	properties   []Property
+}
+
+// Boundary returns a database boundary which can be used to group the schemas
+// or tables of the database at the component diagram level.
+func (db *Database) Boundary() Boundary {
+	return &databaseBoundary{Database: db}
 }
 
 // ID satisfies the Element interface.
 func (db *Database) ID() string { return db.id }
+
+// Name returns the human-readable name of the database.
+func (db *Database) Name() string { return db.name }
+
+// Description returns the description of the database.
+func (db *Database) Description() string { return db.description }
+
+// Technologies returns the technologies describing the database.
+func (db *Database) Technologies() []string { return db.technologies }
+
+// External reports whether the database is styled as an external element.
+func (db *Database) External() bool { return db.external }
+
+// Sprite returns the sprite displayed on the database, if any.
+func (db *Database) Sprite() string { return db.sprite }
+
+// Properties returns the properties describing the database.
+func (db *Database) Properties() []Property { return db.properties }
+
+// Tags returns the tags classifying the database.
+func (db *Database) Tags() []string { return db.tags }
+
+type databaseBoundary struct {
+	*Database
+	elements []Element
+}
+
+func (dbb *databaseBoundary) AddElement(ctx context.Context, el Element) {
+	dbb.elements = append(dbb.elements, el)
+}
+
+func (dbb *databaseBoundary) Elements() []Element { return dbb.elements }
@@ -1,13 +1,19 @@
 package c4
 
-import "context"
+import (
+	"context"
+	"fmt"
+)
 
-// Property represents a key/value pair decribing an aspect of a deployment
-// node.
-type Property struct {
-	Name  string
-	Value string
-}
+// Alignment represents the horizontal placement of the label and description
+// within a deployment node.
+type Alignment string
+
+const (
+	AlignmentCenter Alignment = ""
+	AlignmentLeft   Alignment = "_L"
+	AlignmentRight  Alignment = "_R"
+)
 
 // DeploymentNodeArgs describes the parameters available for configuring a
 // container.
@@ -17,6 +23,19 @@ type DeploymentNodeArgs struct {
 	Description string
 	Properties  []Property
 	Elements    []Element
+	Sprite      string
+
+	// The number of instances of the node e.g. the number of servers in a
+	// farm. Values less than two are not displayed.
+	Instances int
+
+	// The placement of the label and description within the node. Defaults to
+	// AlignmentCenter.
+	Alignment Alignment
+
+	// An optional list of tags classifying the node e.g. "pci". Diagrams can be
+	// filtered by tag using Diagram.Filter.
+	Tags []string
 }
 
 // MustNewDeploymentNode is the same as NewDeploymentNode, but panics on any
@@ -38,6 +57,10 @@ func NewDeploymentNode(ctx context.Context, id string, args DeploymentNodeArgs)
 		description: args.Description,
 		properties:  args.Properties,
 		elements:    args.Elements,
+		sprite:      args.Sprite,
+		instances:   args.Instances,
+		alignment:   args.Alignment,
+		tags:        args.Tags,
 	}
 	return n, nil
 }
@@ -53,12 +76,50 @@ type DeploymentNode struct {
 	description string
 	properties  []Property
 	elements    []Element
+	sprite      string
+	tags        []string
+	instances   int
+	alignment   Alignment
 }
 
+// ID satisfies the Element interface.
 func (dn *DeploymentNode) ID() string {
 	return dn.id
 }
 
+// AddElement adds child elements to the parent DeploymentNode.
 func (dn *DeploymentNode) AddElement(ctx context.Context, el Element) {
 	dn.elements = append(dn.elements, el)
 }
+
+// Name returns the human-readable name of the node.
+func (dn *DeploymentNode) Name() string { return dn.name }
+
+// Type returns the type of the node e.g. Docker Container.
+func (dn *DeploymentNode) Type() string { return dn.nodeType }
+
+// Description returns the description of the node.
+func (dn *DeploymentNode) Description() string { return dn.description }
+
+// Sprite returns the sprite displayed on the node, if any.
+func (dn *DeploymentNode) Sprite() string { return dn.sprite }
+
+// Instances returns the number of instances of the node.
+func (dn *DeploymentNode) Instances() int { return dn.instances }
+
+// Properties returns the properties describing the node.
+func (dn *DeploymentNode) Properties() []Property { return dn.properties }
+
+// Tags returns the tags classifying the node.
+func (dn *DeploymentNode) Tags() []string { return dn.tags }
+
+// Elements returns the child elements of the node.
+func (dn *DeploymentNode) Elements() []Element { return dn.elements }
+
+// label returns the name of the node along with its instance count, if any.
+func (dn *DeploymentNode) label() string {
+	if dn.instances < 2 {
+		return dn.name
+	}
+	return fmt.Sprintf("%s\tx%d", dn.name, dn.instances)
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strings"
 )
 
 // Layout represents the overall layout flow of the resultant diagram.
@@ -59,6 +60,16 @@ type Diagram struct {
 	sketch           bool
 	legend           bool
 	hideElementTypes bool
+	sprites          []SpriteLibrary
+	includeMode      includeMode
+	includeLocation  string
+	libraries        []C4Library
+	propertyHeader   []string
+	instanceRels     bool
+
+	markdownImageServer string
+	ascii               bool
+	diff                bool
 }
 
 // AddElement adds an element to the resultant PlantUML specification.
@@ -81,9 +92,98 @@ func (d *Diagram) NewRelation(ctx context.Context, args RelationArgs, opts ...Re
 	return nil
 }
 
+// Title returns the title of the diagram.
+func (d *Diagram) Title() string { return d.title }
+
+// Elements returns the top-level elements of the diagram. Use the Elements
+// method of a Boundary to access nested elements.
+func (d *Diagram) Elements() []Element { return d.elements }
+
+// Relations returns the arguments of each relation in the diagram in the order
+// they were added.
+func (d *Diagram) Relations() []RelationArgs {
+	args := make([]RelationArgs, 0, len(d.relations))
+	for _, rel := range d.relations {
+		args = append(args, rel.args())
+	}
+	return args
+}
+
+// Validate reports any problems with the diagram that would prevent it from
+// being rendered correctly. Validate is called automatically when rendering.
+func (d *Diagram) Validate(ctx context.Context) error {
+	nodes := map[*DeploymentNode]bool{}
+	walk(d.elements, func(el Element) {
+		if dn, ok := el.(*DeploymentNode); ok {
+			nodes[dn] = true
+		}
+	})
+
+	if err := validateInstances(d.elements, false); err != nil {
+		return err
+	}
+
+	// C4-PlantUML's SetPropertyHeader only accepts between two and four
+	// columns.
+	if n := len(d.propertyHeader); n > 0 && (n < 2 || n > 4) {
+		return fmt.Errorf("invalid property header: expected 2 to 4 columns, got %d", n)
+	}
+
+	var err error
+	walk(d.elements, func(el Element) {
+		lib, _, ok := parseSprite(sprite(el))
+		if err == nil && ok && !contains(d.sprites, lib) {
+			err = fmt.Errorf("invalid sprite %q on %s: the %s sprite library is not enabled", sprite(el), el.ID(), lib)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, rel := range d.relations {
+		for _, el := range []Element{rel.src, rel.dst} {
+			dn, ok := el.(*DeploymentNode)
+			if !ok {
+				continue
+			}
+			if !nodes[dn] {
+				return fmt.Errorf("invalid relation %s -> %s: deployment node %s is not part of a deployment diagram", rel.src.ID(), rel.dst.ID(), dn.ID())
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateInstances reports an error for any instance that isn't nested within
+// a deployment node.
+func validateInstances(els []Element, inNode bool) error {
+	for _, el := range els {
+		switch v := el.(type) {
+		case *Instance:
+			if !inNode {
+				return fmt.Errorf("invalid instance %s: instances must be added to a deployment node", v.ID())
+			}
+		case *DeploymentNode:
+			if err := validateInstances(v.elements, true); err != nil {
+				return err
+			}
+		default:
+			if err := validateInstances(children(el), inNode); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // PlantUML renders the Diagram as a C4-enabled PlantUML specification to the
 // provided writer.
 func (d *Diagram) PlantUML(ctx context.Context, w io.Writer) error {
+	if err := d.Validate(ctx); err != nil {
+		return err
+	}
+
 	var buff bytes.Buffer
 
 	if err := d.writePreamble(ctx, &buff, d.title, d.layout); err != nil {
@@ -96,7 +196,7 @@ func (d *Diagram) PlantUML(ctx context.Context, w io.Writer) error {
 		}
 	}
 
-	for _, rel := range d.relations {
+	for _, rel := range d.renderedRelations() {
 		if err := plantUML(ctx, &buff, rel); err != nil {
 			return err
 		}
@@ -113,14 +213,51 @@ func (d *Diagram) PlantUML(ctx context.Context, w io.Writer) error {
 	return nil
 }
 
+// renderedRelations returns the relations to render, expanded to include
+// instances if enabled.
+func (d *Diagram) renderedRelations() []*relation {
+	if d.instanceRels {
+		return d.expandInstanceRelations()
+	}
+	return d.relations
+}
+
 func (d *Diagram) writePreamble(ctx context.Context, buff *bytes.Buffer, title string, layout Layout) error {
 	fmt.Fprintln(buff, "@startuml", title)
-	fmt.Fprintln(buff, "!include https://raw.githubusercontent.com/plantuml-stdlib/C4-PlantUML/master/C4_Container.puml")
-	fmt.Fprintln(buff, "!include https://raw.githubusercontent.com/plantuml-stdlib/C4-PlantUML/master/C4_Component.puml")
-	fmt.Fprintln(buff, "!include https://raw.githubusercontent.com/plantuml-stdlib/C4-PlantUML/master/C4_Deployment.puml")
+	libs := d.libraries
+	if len(libs) == 0 {
+		libs = requiredLibraries(d.elements)
+	}
+	if err := d.writeIncludes(buff, libs); err != nil {
+		return err
+	}
 	fmt.Fprintln(buff)
-	fmt.Fprintln(buff, "WithoutPropertyHeader()")
+	if includes := spriteIncludes(d.elements, d.sprites); len(includes) > 0 {
+		for _, include := range includes {
+			fmt.Fprintf(buff, "!include <%s>\n", include)
+		}
+		fmt.Fprintln(buff)
+	}
+	if len(d.propertyHeader) > 0 {
+		fmt.Fprintf(buff, "SetPropertyHeader(\"%s\")\n", strings.Join(d.propertyHeader, `", "`))
+	} else {
+		fmt.Fprintln(buff, "WithoutPropertyHeader()")
+	}
 	fmt.Fprintln(buff)
+	hasInfrastructure := false
+	walk(d.elements, func(el Element) {
+		if _, ok := el.(*InfrastructureNode); ok {
+			hasInfrastructure = true
+		}
+	})
+	if hasInfrastructure {
+		fmt.Fprintf(buff, `AddElementTag("%s", $legendText="infrastructure node")`, infrastructureNodeTag)
+		fmt.Fprintln(buff)
+		fmt.Fprintln(buff)
+	}
+	if d.diff {
+		writeDiffTags(buff)
+	}
 	fmt.Fprintf(buff, "%s()\n", layout)
 	if d.sketch {
 		fmt.Fprintln(buff, `LAYOUT_AS_SKETCH()`)
@@ -182,6 +319,14 @@ func WithLayout(l Layout) DiagramOption {
 	}
 }
 
+// WithASCII draws text diagrams using only ASCII characters, for terminals and
+// fonts that don't support box-drawing characters.
+func WithASCII() DiagramOption {
+	return func(d *Diagram) {
+		d.ascii = true
+	}
+}
+
 // WithLegend enables a legend mapping colors to element types.
 func WithLegend() DiagramOption {
 	return func(d *Diagram) {
@@ -189,6 +334,44 @@ func WithLegend() DiagramOption {
 	}
 }
 
+// WithInstanceRelations copies relations declared on containers, databases and
+// queues onto each of their instances in the diagram. This allows relations to
+// be declared once against the logical element rather than repeated for every
+// instance.
+func WithInstanceRelations() DiagramOption {
+	return func(d *Diagram) {
+		d.instanceRels = true
+	}
+}
+
+// WithMarkdownImageServer embeds an image of the diagram from a PlantUML server
+// in Markdown reports, rather than Mermaid source. The server should include
+// the path for the desired image format, as for EncodedURL.
+func WithMarkdownImageServer(server string) DiagramOption {
+	return func(d *Diagram) {
+		d.markdownImageServer = server
+	}
+}
+
+// WithPropertyHeader displays a header row with the given column names on
+// every properties table in the diagram e.g. WithPropertyHeader("Property",
+// "Value"). Between two and four columns may be given. By default, properties
+// tables are displayed without a header.
+func WithPropertyHeader(columns ...string) DiagramOption {
+	return func(d *Diagram) {
+		d.propertyHeader = columns
+	}
+}
+
+// WithSprites enables icons from the given sprite libraries to be used as
+// element sprites. Only the parts of each library that are used by elements in
+// the diagram are included in the resultant PlantUML.
+func WithSprites(libs ...SpriteLibrary) DiagramOption {
+	return func(d *Diagram) {
+		d.sprites = append(d.sprites, libs...)
+	}
+}
+
 // WithTheme allows you to set a custom theme for the diagram. You can either
 // create a theme from scratch or use c4.DefaultTheme() and modify the values
 // you care about.
@@ -197,3 +380,100 @@ func WithTheme(t Theme) DiagramOption {
 		d.theme = t
 	}
 }
+
+// children returns the elements grouped by el, if any. Boundaries and
+// deployment nodes created by this package expose their children through an
+// Elements method, which isn't part of the Boundary interface.
+func children(el Element) []Element {
+	if g, ok := el.(interface{ Elements() []Element }); ok {
+		return g.Elements()
+	}
+	return nil
+}
+
+// walk calls fn for each element in els and, recursively, for each of their
+// children.
+func walk(els []Element, fn func(Element)) {
+	for _, el := range els {
+		fn(el)
+		walk(children(el), fn)
+	}
+}
+
+// withChildren returns a copy of the boundary el containing els in place of its
+// children. Systems, containers and databases left without children are no
+// longer shown as boundaries. Other elements are returned unchanged.
+func withChildren(el Element, els []Element) Element {
+	switch v := el.(type) {
+	case *containerBoundary:
+		if len(els) == 0 {
+			return v.Container
+		}
+		return &containerBoundary{Container: v.Container, elements: els}
+	case *databaseBoundary:
+		if len(els) == 0 {
+			return v.Database
+		}
+		return &databaseBoundary{Database: v.Database, elements: els}
+	case *DeploymentNode:
+		dn := *v
+		dn.elements = els
+		return &dn
+	case *EnterpriseBoundary:
+		eb := *v
+		eb.elements = els
+		return &eb
+	case *systemBoundary:
+		if len(els) == 0 {
+			return v.System
+		}
+		return &systemBoundary{System: v.System, elements: els}
+	}
+	return el
+}
+
+// derive returns a copy of the diagram containing the elements for which keep
+// returns true, along with the relations between them. Boundaries are passed
+// the children that were kept, so that they can be kept whenever they still
+// group other elements. Relations are copied as displayed, so relations copied
+// onto instances are kept without the original relation.
+func (d *Diagram) derive(keep func(el Element, kept []Element) bool) *Diagram {
+	var prune func(els []Element) []Element
+	prune = func(els []Element) []Element {
+		var pruned []Element
+		for _, el := range els {
+			kept := prune(children(el))
+			if !keep(el, kept) {
+				continue
+			}
+			pruned = append(pruned, withChildren(el, kept))
+		}
+		return pruned
+	}
+
+	derived := *d
+	derived.elements = prune(d.elements)
+	derived.relations = nil
+	derived.instanceRels = false
+
+	byID := map[string]Element{}
+	walk(derived.elements, func(el Element) {
+		byID[el.ID()] = el
+	})
+	for _, rel := range d.renderedRelations() {
+		src, ok := byID[rel.src.ID()]
+		if !ok {
+			continue
+		}
+		dst, ok := byID[rel.dst.ID()]
+		if !ok {
+			continue
+		}
+		r := *rel
+		r.src = src
+		r.dst = dst
+		derived.relations = append(derived.relations, &r)
+	}
+
+	return &derived
+}
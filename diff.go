@@ -0,0 +1,425 @@
+package c4
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ChangeType describes how an element or relation differs between two versions
+// of a diagram.
+type ChangeType string
+
+const (
+	ChangeAdded   ChangeType = "added"
+	ChangeRemoved ChangeType = "removed"
+	ChangeChanged ChangeType = "changed"
+)
+
+// Colors used to display changes in diff diagrams.
+var diffColors = map[ChangeType]string{
+	ChangeAdded:   "#2E7D32",
+	ChangeRemoved: "#C62828",
+	ChangeChanged: "#F9A825",
+}
+
+// FieldChange describes a change to a single field of an element or relation.
+// Technologies are compared as a comma-separated list, and each property is
+// compared separately as a field named after the property e.g.
+// "properties[Owner]".
+type FieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// ElementChange describes an element that differs between two diagrams.
+type ElementChange struct {
+	// The identifier of the element.
+	ID string
+
+	Type ChangeType
+
+	// The element in each diagram. Old is nil for added elements, and New is
+	// nil for removed elements.
+	Old Element
+	New Element
+
+	// The fields that differ, for changed elements.
+	Fields []FieldChange
+}
+
+// RelationChange describes a relation that differs between two diagrams.
+type RelationChange struct {
+	// The identifier of the relation, made up of the identifiers of its
+	// elements e.g. "api->db". Additional relations between the same elements
+	// are numbered in the order they were added e.g. "api->db#2".
+	ID string
+
+	Type ChangeType
+
+	// The relation in each diagram. Old is nil for added relations, and New
+	// is nil for removed relations.
+	Old *RelationArgs
+	New *RelationArgs
+
+	// The fields that differ, for changed relations.
+	Fields []FieldChange
+}
+
+// Changes describes the differences between two versions of a diagram. Changes
+// are listed in the order the elements and relations appear in the new
+// diagram, followed by anything that was removed.
+type Changes struct {
+	Elements  []ElementChange
+	Relations []RelationChange
+
+	old *Diagram
+	new *Diagram
+}
+
+// Diff compares two versions of a diagram, matching elements by their
+// identifiers and relations by the identifiers of their elements. This makes it
+// possible to review what changed in an architecture, rather than comparing
+// images.
+//
+//	changes, err := c4.Diff(ctx, before, after)
+//	if err != nil {
+//		return err
+//	}
+//	for _, change := range changes.Elements {
+//		fmt.Println(change.Type, change.ID)
+//	}
+func Diff(ctx context.Context, old, new *Diagram) (*Changes, error) {
+	if err := old.Validate(ctx); err != nil {
+		return nil, fmt.Errorf("diff: old: %w", err)
+	}
+	if err := new.Validate(ctx); err != nil {
+		return nil, fmt.Errorf("diff: new: %w", err)
+	}
+
+	c := &Changes{old: old, new: new}
+
+	oldIDs, oldEls := diffElements(old)
+	newIDs, newEls := diffElements(new)
+	for _, id := range newIDs {
+		n := newEls[id]
+		o, ok := oldEls[id]
+		if !ok {
+			c.Elements = append(c.Elements, ElementChange{ID: id, Type: ChangeAdded, New: n})
+			continue
+		}
+		if fields := elementFields(o, n); len(fields) > 0 {
+			c.Elements = append(c.Elements, ElementChange{ID: id, Type: ChangeChanged, Old: o, New: n, Fields: fields})
+		}
+	}
+	for _, id := range oldIDs {
+		if _, ok := newEls[id]; !ok {
+			c.Elements = append(c.Elements, ElementChange{ID: id, Type: ChangeRemoved, Old: oldEls[id]})
+		}
+	}
+
+	oldRelIDs, oldRels := diffRelations(old)
+	newRelIDs, newRels := diffRelations(new)
+	for _, id := range newRelIDs {
+		n := newRels[id].args()
+		o, ok := oldRels[id]
+		if !ok {
+			c.Relations = append(c.Relations, RelationChange{ID: id, Type: ChangeAdded, New: &n})
+			continue
+		}
+		oa := o.args()
+		if fields := relationFields(oa, n); len(fields) > 0 {
+			c.Relations = append(c.Relations, RelationChange{ID: id, Type: ChangeChanged, Old: &oa, New: &n, Fields: fields})
+		}
+	}
+	for _, id := range oldRelIDs {
+		if _, ok := newRels[id]; !ok {
+			o := oldRels[id].args()
+			c.Relations = append(c.Relations, RelationChange{ID: id, Type: ChangeRemoved, Old: &o})
+		}
+	}
+
+	return c, nil
+}
+
+// Empty reports whether the diagrams are the same.
+func (c *Changes) Empty() bool {
+	return len(c.Elements) == 0 && len(c.Relations) == 0
+}
+
+// Diagram returns a diagram combining the elements and relations of both
+// versions, with added elements and relations displayed in green, removed ones
+// in red and changed ones in amber. Removed elements are displayed within
+// their original boundary or deployment node where it still exists. The
+// diagram has the title and options of the new diagram, along with any
+// options given.
+//
+// Changes are only colored in PlantUML output.
+func (c *Changes) Diagram(ctx context.Context, opts ...DiagramOption) (*Diagram, error) {
+	elementTypes := map[string]ChangeType{}
+	for _, change := range c.Elements {
+		elementTypes[change.ID] = change.Type
+	}
+	relationTypes := map[string]ChangeType{}
+	for _, change := range c.Relations {
+		relationTypes[change.ID] = change.Type
+	}
+
+	d := *c.new
+	d.relations = nil
+	d.instanceRels = false
+	d.diff = true
+	d.elements = diffMerge(c.old.elements, c.new.elements, elementTypes)
+
+	byID := map[string]Element{}
+	walk(d.elements, func(el Element) {
+		byID[el.ID()] = el
+	})
+	add := func(ids []string, rels map[string]*relation, removed bool) {
+		for _, id := range ids {
+			typ, ok := relationTypes[id]
+			if removed && typ != ChangeRemoved {
+				continue
+			}
+			r := *rels[id]
+			if el, ok := byID[r.src.ID()]; ok {
+				r.src = el
+			}
+			if el, ok := byID[r.dst.ID()]; ok {
+				r.dst = el
+			}
+			r.tags = diffTags(r.tags, typ, ok)
+			d.relations = append(d.relations, &r)
+		}
+	}
+	newIDs, newRels := diffRelations(c.new)
+	add(newIDs, newRels, false)
+	oldIDs, oldRels := diffRelations(c.old)
+	add(oldIDs, oldRels, true)
+
+	for _, opt := range opts {
+		opt(&d)
+	}
+
+	if err := d.Validate(ctx); err != nil {
+		return nil, fmt.Errorf("diff: %w", err)
+	}
+
+	return &d, nil
+}
+
+// writeDiffTags defines the tags used to color the changes in a diff diagram.
+func writeDiffTags(buff *bytes.Buffer) {
+	for _, typ := range []ChangeType{ChangeAdded, ChangeRemoved, ChangeChanged} {
+		color := diffColors[typ]
+		fmt.Fprintf(buff, `AddElementTag("%s", $bgColor="%s", $borderColor="%s", $legendText="%s")`, typ, color, color, typ)
+		fmt.Fprintln(buff)
+		fmt.Fprintf(buff, `AddBoundaryTag("%s", $fontColor="%s", $borderColor="%s", $legendText="%s")`, typ, color, color, typ)
+		fmt.Fprintln(buff)
+		fmt.Fprintf(buff, `AddRelTag("%s", $textColor="%s", $lineColor="%s", $legendText="%s")`, typ, color, color, typ)
+		fmt.Fprintln(buff)
+	}
+	fmt.Fprintln(buff)
+}
+
+// diffElements returns the identifiers of every element in the diagram, in
+// the order they appear, along with the elements themselves.
+func diffElements(d *Diagram) ([]string, map[string]Element) {
+	var ids []string
+	els := map[string]Element{}
+	walk(d.elements, func(el Element) {
+		ids = append(ids, el.ID())
+		els[el.ID()] = el
+	})
+	return ids, els
+}
+
+// diffRelations returns the identifiers of the relations displayed in the
+// diagram, in the order they were added, along with the relations themselves.
+func diffRelations(d *Diagram) ([]string, map[string]*relation) {
+	var ids []string
+	rels := map[string]*relation{}
+	for _, rel := range d.renderedRelations() {
+		id := rel.src.ID() + "->" + rel.dst.ID()
+		for n := 2; rels[id] != nil; n++ {
+			id = fmt.Sprintf("%s->%s#%d", rel.src.ID(), rel.dst.ID(), n)
+		}
+		ids = append(ids, id)
+		rels[id] = rel
+	}
+	return ids, rels
+}
+
+// diffMerge combines the elements of both versions of a level of the diagram,
+// tagging any that changed. Removed elements are added after the elements that
+// remain.
+func diffMerge(oldEls, newEls []Element, types map[string]ChangeType) []Element {
+	olds := map[string]Element{}
+	for _, el := range oldEls {
+		olds[el.ID()] = el
+	}
+
+	var els []Element
+	for _, el := range newEls {
+		var oldChildren []Element
+		if o, ok := olds[el.ID()]; ok {
+			oldChildren = children(o)
+		}
+		merged, orphans := diffTag(el, types, diffMerge(oldChildren, children(el), types))
+		els = append(els, merged)
+		els = append(els, orphans...)
+	}
+	for _, el := range oldEls {
+		if types[el.ID()] != ChangeRemoved {
+			continue
+		}
+		merged, orphans := diffTag(el, types, diffMerge(children(el), nil, types))
+		els = append(els, merged)
+		els = append(els, orphans...)
+	}
+	return els
+}
+
+// diffTag returns a copy of el tagged with its change, if any, containing the
+// given children. Elements that can't contain children return them as orphans
+// to be displayed alongside the element instead.
+func diffTag(el Element, types map[string]ChangeType, els []Element) (Element, []Element) {
+	typ, changed := types[el.ID()]
+	tags := diffTags(tagsOf(el), typ, changed)
+
+	if inst, ok := el.(*Instance); ok {
+		if !changed {
+			return el, els
+		}
+		el = inst.element()
+	}
+
+	switch v := el.(type) {
+	case *Component:
+		c := *v
+		c.tags = tags
+		return &c, els
+	case *Container:
+		c := *v
+		c.tags = tags
+		return &c, els
+	case *containerBoundary:
+		c := *v.Container
+		c.tags = tags
+		return &containerBoundary{Container: &c, elements: els}, nil
+	case *Database:
+		db := *v
+		db.tags = tags
+		return &db, els
+	case *databaseBoundary:
+		db := *v.Database
+		db.tags = tags
+		return &databaseBoundary{Database: &db, elements: els}, nil
+	case *DeploymentNode:
+		dn := *v
+		dn.tags = tags
+		dn.elements = els
+		return &dn, nil
+	case *EnterpriseBoundary:
+		eb := *v
+		eb.tags = tags
+		eb.elements = els
+		return &eb, nil
+	case *InfrastructureNode:
+		n := *v
+		n.tags = tags
+		return &n, els
+	case *Person:
+		p := *v
+		p.tags = tags
+		return &p, els
+	case *Queue:
+		q := *v
+		q.tags = tags
+		return &q, els
+	case *System:
+		s := *v
+		s.tags = tags
+		return &s, els
+	case *systemBoundary:
+		s := *v.System
+		s.tags = tags
+		return &systemBoundary{System: &s, elements: els}, nil
+	}
+	return el, els
+}
+
+// diffTags returns a copy of tags with the tag for the change appended, if
+// there is one, so that the element keeps any tags of its own.
+func diffTags(tags []string, typ ChangeType, changed bool) []string {
+	if !changed {
+		return tags
+	}
+	return append(append([]string(nil), tags...), string(typ))
+}
+
+func elementFields(old, new Element) []FieldChange {
+	o, n := summarize(old), summarize(new)
+	var fields []FieldChange
+	add := func(field, old, new string) {
+		if old != new {
+			fields = append(fields, FieldChange{Field: field, Old: old, New: new})
+		}
+	}
+	add("name", o.name, n.name)
+	add("type", o.kind, n.kind)
+	add("description", o.description, n.description)
+	add("technologies", strings.Join(o.technologies, ", "), strings.Join(n.technologies, ", "))
+	add("external", strconv.FormatBool(o.external), strconv.FormatBool(n.external))
+	return append(fields, propertyFields(properties(old), properties(new))...)
+}
+
+func relationFields(old, new RelationArgs) []FieldChange {
+	var fields []FieldChange
+	if old.Description != new.Description {
+		fields = append(fields, FieldChange{Field: "description", Old: old.Description, New: new.Description})
+	}
+	if o, n := strings.Join(old.Technologies, ", "), strings.Join(new.Technologies, ", "); o != n {
+		fields = append(fields, FieldChange{Field: "technologies", Old: o, New: n})
+	}
+	return append(fields, propertyFields(old.Properties, new.Properties)...)
+}
+
+// propertyFields compares properties by name. Properties that were added or
+// removed are reported with an empty old or new value.
+func propertyFields(old, new []Property) []FieldChange {
+	olds := map[string]string{}
+	for _, p := range old {
+		olds[p.Name] = p.Value
+	}
+	news := map[string]bool{}
+
+	var fields []FieldChange
+	for _, p := range new {
+		news[p.Name] = true
+		if o, ok := olds[p.Name]; !ok || o != p.Value {
+			fields = append(fields, FieldChange{Field: "properties[" + p.Name + "]", Old: o, New: p.Value})
+		}
+	}
+	for _, p := range old {
+		if !news[p.Name] {
+			fields = append(fields, FieldChange{Field: "properties[" + p.Name + "]", Old: p.Value})
+		}
+	}
+	return fields
+}
+
+// properties returns the properties of el, or of the element it is an
+// instance of.
+func properties(el Element) []Property {
+	if inst, ok := el.(*Instance); ok {
+		el = inst.Of()
+	}
+	if v, ok := el.(interface{ Properties() []Property }); ok {
+		return v.Properties()
+	}
+	return nil
+}
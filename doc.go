@@ -135,6 +135,29 @@
 //
 //	d.AddElement(ctx, apiApplicationBoundary)
 //
+// # Sprites
+//
+// Most elements accept an optional sprite which is displayed as an icon
+// alongside the element's name. Icons from the PlantUML standard library are
+// referenced by library and path, and the library must be enabled on the
+// diagram using the WithSprites option:
+//
+//	database, _ := c4.NewDatabase(ctx, "database", c4.DatabaseArgs{
+//		Name:   "Database",
+//		Sprite: "devicons/postgresql",
+//	})
+//
+//	d, _ := c4.NewDiagram(ctx, "Demo", c4.WithSprites(c4.SpritesDevicons))
+//
+// # Including C4-PlantUML
+//
+// By default, the resultant PlantUML includes the C4-PlantUML library from the
+// master branch of the upstream repository, which requires network access when
+// rendering. The WithC4Version, WithC4Stdlib, WithC4Directory and
+// WithEmbeddedC4 options allow pinning a release, using the copy bundled with
+// PlantUML, using a local copy, or writing out a fully self-contained
+// specification respectively.
+//
 // # Theming
 //
 // By default, diagrams are styled using a default theme designed to be neutral
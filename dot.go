@@ -0,0 +1,254 @@
+package c4
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// Colors used for external elements, matching the C4-PlantUML defaults.
+const (
+	dotExternalPersonColor    = "#686868"
+	dotExternalSystemColor    = "#999999"
+	dotExternalContainerColor = "#B3B3B3"
+	dotExternalComponentColor = "#CCCCCC"
+	dotExternalFontColor      = "#FFFFFF"
+	dotBoundaryColor          = "#444444"
+	dotRelationColor          = "#707070"
+)
+
+// DOT renders the Diagram as a Graphviz DOT graph to the provided writer.
+// Boundaries and deployment nodes are drawn as clusters and elements are
+// colored using the diagram theme. Options that only affect PlantUML output,
+// such as sprites, properties and includes, are ignored.
+func (d *Diagram) DOT(ctx context.Context, w io.Writer) error {
+	if err := d.Validate(ctx); err != nil {
+		return err
+	}
+
+	relations := d.renderedRelations()
+
+	// Relations may refer to a boundary, which has no node of its own, so an
+	// invisible anchor node is placed within each referenced cluster.
+	clusters := map[string]bool{}
+	walk(d.elements, func(el Element) {
+		if _, ok := el.(Boundary); ok {
+			clusters[el.ID()] = true
+		}
+	})
+	anchors := map[string]bool{}
+	for _, rel := range relations {
+		for _, el := range []Element{rel.src, rel.dst} {
+			if clusters[el.ID()] {
+				anchors[el.ID()] = true
+			}
+		}
+	}
+
+	dw := &dotWriter{theme: d.theme, anchors: anchors}
+
+	var buff bytes.Buffer
+
+	rankdir := "TB"
+	if d.layout == LayoutLeftRight || d.layout == LayoutLandscape {
+		rankdir = "LR"
+	}
+	fmt.Fprintf(&buff, "digraph %s {\n", dotID(d.title))
+	fmt.Fprintf(&buff, "\tgraph [label=%s, labelloc=t, fontname=\"Helvetica\", rankdir=%s, compound=true, nodesep=0.6, ranksep=0.8]\n", dotID(d.title), rankdir)
+	fmt.Fprintln(&buff, "\tnode [shape=box, style=\"rounded,filled\", fontname=\"Helvetica\", fontsize=11, margin=\"0.3,0.15\"]")
+	fmt.Fprintf(&buff, "\tedge [fontname=\"Helvetica\", fontsize=9, color=%q, fontcolor=%q]\n", dotRelationColor, dotRelationColor)
+	fmt.Fprintln(&buff)
+
+	if err := dw.writeChildren(ctx, &buff, d.elements); err != nil {
+		return err
+	}
+	for _, rel := range relations {
+		fmt.Fprint(&buff, "\t")
+		if err := dw.write(ctx, &buff, rel); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(&buff, "}")
+
+	if _, err := io.Copy(w, &buff); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type dotWriter struct {
+	theme   Theme
+	anchors map[string]bool
+}
+
+func (dw *dotWriter) write(ctx context.Context, w io.Writer, el interface{}) error {
+	switch v := el.(type) {
+	case *Component:
+		p := dw.theme.Component
+		if v.external {
+			p = Palette{BackgroundColor: dotExternalComponentColor, FontColor: dotExternalFontColor}
+		}
+		dotNode(w, v.ID(), dotLabel(v.name, "Component", v.technologies, v.description), p, v.shape == ShapeDatabase)
+	case *Container:
+		dotNode(w, v.ID(), dotLabel(v.name, "Container", v.technologies, v.description), dw.containerPalette(v.external), false)
+	case *containerBoundary:
+		return dw.writeCluster(ctx, w, v.ID(), dotLabel(v.name, "Container", nil, ""), true, v.elements)
+	case *databaseBoundary:
+		return dw.writeCluster(ctx, w, v.ID(), dotLabel(v.name, "Container", nil, ""), true, v.elements)
+	case *EnterpriseBoundary:
+		return dw.writeCluster(ctx, w, v.ID(), dotLabel(v.name, "Enterprise", nil, ""), true, v.elements)
+	case *Database:
+		dotNode(w, v.ID(), dotLabel(v.name, "Container", v.technologies, v.description), dw.containerPalette(v.external), true)
+	case *DeploymentNode:
+		name := v.name
+		if v.instances > 1 {
+			name = fmt.Sprintf("%s x%d", v.name, v.instances)
+		}
+		var types []string
+		if v.nodeType != "" {
+			types = []string{v.nodeType}
+		}
+		return dw.writeCluster(ctx, w, v.ID(), dotLabel(name, "Deployment Node", types, v.description), false, v.elements)
+	case *InfrastructureNode:
+		dotNode(w, v.ID(), dotLabel(v.name, "Infrastructure Node", v.technologies, v.description), Palette{BackgroundColor: "#FFFFFF", FontColor: "#000000"}, false)
+	case *Instance:
+		return dw.write(ctx, w, v.element())
+	case *Person:
+		p := dw.theme.Person
+		if v.external {
+			p = Palette{BackgroundColor: dotExternalPersonColor, FontColor: dotExternalFontColor}
+		}
+		dotNode(w, v.ID(), dotLabel(v.name, "Person", nil, v.description), p, false)
+	case *Queue:
+		dotNode(w, v.ID(), dotLabel(v.name, "Container", v.technologies, v.description), dw.containerPalette(v.external), false)
+	case *relation:
+		attrs := []string{"label=<" + dotRelationLabel(v.description, v.technologies) + ">"}
+		src, dst := v.src.ID(), v.dst.ID()
+		if dw.anchors[src] {
+			attrs = append(attrs, "ltail="+dotID("cluster_"+src))
+			src = src + "__anchor"
+		}
+		if dw.anchors[dst] {
+			attrs = append(attrs, "lhead="+dotID("cluster_"+dst))
+			dst = dst + "__anchor"
+		}
+		fmt.Fprintf(w, "%s -> %s [%s]\n", dotID(src), dotID(dst), strings.Join(attrs, ", "))
+	case *systemBoundary:
+		return dw.writeCluster(ctx, w, v.ID(), dotLabel(v.name, "Software System", nil, ""), true, v.elements)
+	case *System:
+		p := dw.theme.System
+		if v.external {
+			p = Palette{BackgroundColor: dotExternalSystemColor, FontColor: dotExternalFontColor}
+		}
+		dotNode(w, v.ID(), dotLabel(v.name, "Software System", nil, v.description), p, v.shape == ShapeDatabase)
+	default:
+		return fmt.Errorf("cannot create dot: invalid item type: %T", el)
+	}
+	return nil
+}
+
+func (dw *dotWriter) containerPalette(external bool) Palette {
+	if external {
+		return Palette{BackgroundColor: dotExternalContainerColor, FontColor: dotExternalFontColor}
+	}
+	return dw.theme.Container
+}
+
+func (dw *dotWriter) writeCluster(ctx context.Context, w io.Writer, id, label string, dashed bool, els []Element) error {
+	style := "solid"
+	if dashed {
+		style = "dashed"
+	}
+	fmt.Fprintf(w, "subgraph %s {\n", dotID("cluster_"+id))
+	fmt.Fprintf(w, "\tlabel=<%s>\n", label)
+	fmt.Fprintf(w, "\tstyle=%s\n", style)
+	fmt.Fprintf(w, "\tcolor=%q\n", dotBoundaryColor)
+	fmt.Fprintf(w, "\tfontcolor=%q\n", dotBoundaryColor)
+	if dw.anchors[id] {
+		fmt.Fprintf(w, "\t%s [shape=point, style=invis, width=0, height=0, label=\"\"]\n", dotID(id+"__anchor"))
+	}
+	if err := dw.writeChildren(ctx, w, els); err != nil {
+		return err
+	}
+	fmt.Fprintln(w, "}")
+	return nil
+}
+
+func (dw *dotWriter) writeChildren(ctx context.Context, w io.Writer, els []Element) error {
+	for _, el := range els {
+		var buff bytes.Buffer
+		if err := dw.write(ctx, &buff, el); err != nil {
+			return err
+		}
+		s := bufio.NewScanner(&buff)
+		for s.Scan() {
+			fmt.Fprintf(w, "\t%s\n", s.Text())
+		}
+	}
+	return nil
+}
+
+func dotNode(w io.Writer, id, label string, p Palette, cylinder bool) {
+	shape := ""
+	if cylinder {
+		shape = ", shape=cylinder, style=filled"
+	}
+	fmt.Fprintf(w, "%s [label=<%s>, fillcolor=%q, fontcolor=%q, color=%q%s]\n", dotID(id), label, p.BackgroundColor, p.FontColor, p.BackgroundColor, shape)
+}
+
+// dotLabel returns an HTML-like label in the style of C4-PlantUML e.g. the
+// name in bold followed by "[Container: Java, Spring MVC]" and a wrapped
+// description.
+func dotLabel(name, kind string, technologies []string, description string) string {
+	typ := kind
+	if len(technologies) > 0 {
+		typ += ": " + strings.Join(technologies, ", ")
+	}
+	label := fmt.Sprintf("<b>%s</b><br/><font point-size=\"9\">[%s]</font>", html.EscapeString(name), html.EscapeString(typ))
+	if description != "" {
+		label += "<br/><br/>" + strings.Join(wrap(html.EscapeString(description), 40), "<br/>")
+	}
+	return label
+}
+
+func dotRelationLabel(description string, technologies []string) string {
+	label := strings.Join(wrap(html.EscapeString(description), 30), "<br/>")
+	if len(technologies) > 0 {
+		label += "<br/><font point-size=\"8\">[" + html.EscapeString(strings.Join(technologies, ", ")) + "]</font>"
+	}
+	return label
+}
+
+// dotID quotes s for use as a DOT identifier.
+func dotID(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// wrap splits s into lines of at most width characters, breaking on spaces.
+// Words longer than width are kept whole.
+func wrap(s string, width int) []string {
+	var (
+		lines []string
+		line  string
+	)
+	for _, word := range strings.Fields(s) {
+		switch {
+		case line == "":
+			line = word
+		case len(line)+1+len(word) > width:
+			lines = append(lines, line)
+			line = word
+		default:
+			line += " " + word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
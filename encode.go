@@ -0,0 +1,63 @@
+package c4
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"encoding/base64"
+	"strings"
+)
+
+// plantUMLAlphabet is the variant of base64 used by PlantUML to encode diagrams
+// in URLs.
+const plantUMLAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz-_"
+
+var plantUMLEncoding = base64.NewEncoding(plantUMLAlphabet).WithPadding(base64.NoPadding)
+
+// EncodedURL returns a URL for the diagram on a PlantUML server. The server
+// should include the path for the desired image format e.g.
+// "https://www.plantuml.com/plantuml/svg". The diagram is compressed and
+// encoded into the URL itself, which makes it suitable for embedding images
+// in markdown without storing them.
+//
+//	url, err := d.EncodedURL(ctx, "https://www.plantuml.com/plantuml/svg")
+//	if err != nil {
+//		return err
+//	}
+//	fmt.Printf("![%s](%s)\n", d.Title(), url)
+func (d *Diagram) EncodedURL(ctx context.Context, server string) (string, error) {
+	var buff bytes.Buffer
+	if err := d.PlantUML(ctx, &buff); err != nil {
+		return "", err
+	}
+	enc, err := EncodePlantUML(buff.Bytes())
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(server, "/") + "/" + enc, nil
+}
+
+// EncodePlantUML encodes PlantUML source in the text encoding used by PlantUML
+// servers: the source is compressed using deflate and then encoded using a
+// variant of base64.
+func EncodePlantUML(src []byte) (string, error) {
+	var buff bytes.Buffer
+	zw, err := flate.NewWriter(&buff, flate.BestCompression)
+	if err != nil {
+		return "", err
+	}
+	if _, err := zw.Write(src); err != nil {
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+
+	// PlantUML encodes a trailing partial group as if it were padded with
+	// zero bytes, rather than leaving it short.
+	enc := plantUMLEncoding.EncodeToString(buff.Bytes())
+	if n := len(enc) % 4; n != 0 {
+		enc += strings.Repeat("0", 4-n)
+	}
+	return enc, nil
+}
@@ -0,0 +1,88 @@
+package c4
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+// decodePlantUML reverses EncodePlantUML.
+func decodePlantUML(t *testing.T, enc string) string {
+	t.Helper()
+	compressed, err := plantUMLEncoding.DecodeString(enc)
+	if err != nil {
+		t.Fatalf("decode %q: %v", enc, err)
+	}
+	// Any zero bytes added by padding follow the end of the deflate stream,
+	// so they are never read.
+	src, err := io.ReadAll(flate.NewReader(bytes.NewReader(compressed)))
+	if err != nil {
+		t.Fatalf("inflate %q: %v", enc, err)
+	}
+	return string(src)
+}
+
+func TestDecodePlantUMLExample(t *testing.T) {
+	// The example from https://plantuml.com/text-encoding, produced by
+	// PlantUML itself. This checks that the alphabet and padding used by the
+	// tests match those used by PlantUML.
+	const enc = "SyfFKj2rKt3CoKnELR1Io4ZDoSa70000"
+	if got, want := decodePlantUML(t, enc), "Bob -> Alice : hello"; got != want {
+		t.Errorf("decodePlantUML(%q) = %q, want %q", enc, got, want)
+	}
+}
+
+func TestEncodePlantUML(t *testing.T) {
+	tests := []string{
+		"Bob -> Alice : hello",
+		"@startuml\nPerson(customer, \"Customer\")\n@enduml\n",
+		"",
+		strings.Repeat("Rel(a, b, \"Uses\")\n", 100),
+	}
+	for _, src := range tests {
+		enc, err := EncodePlantUML([]byte(src))
+		if err != nil {
+			t.Fatalf("EncodePlantUML(%q): %v", src, err)
+		}
+		if len(enc)%4 != 0 {
+			t.Errorf("EncodePlantUML(%q) = %q, want a multiple of 4 characters", src, enc)
+		}
+		if i := strings.IndexFunc(enc, func(r rune) bool { return !strings.ContainsRune(plantUMLAlphabet, r) }); i >= 0 {
+			t.Errorf("EncodePlantUML(%q) = %q, contains %q which isn't in the PlantUML alphabet", src, enc, enc[i])
+		}
+		if got := decodePlantUML(t, enc); got != src {
+			t.Errorf("EncodePlantUML(%q) decodes to %q", src, got)
+		}
+	}
+}
+
+func TestEncodedURL(t *testing.T) {
+	ctx := context.Background()
+	d, err := NewDiagram(ctx, "Encoded")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.AddElement(ctx, MustNewPerson(ctx, "customer", PersonArgs{Name: "Customer"}))
+
+	var src bytes.Buffer
+	if err := d.PlantUML(ctx, &src); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, server := range []string{"https://plantuml.example.com/svg", "https://plantuml.example.com/svg/"} {
+		url, err := d.EncodedURL(ctx, server)
+		if err != nil {
+			t.Fatalf("EncodedURL(%q): %v", server, err)
+		}
+		const prefix = "https://plantuml.example.com/svg/"
+		if !strings.HasPrefix(url, prefix) {
+			t.Fatalf("EncodedURL(%q) = %q, want prefix %q", server, url, prefix)
+		}
+		if got := decodePlantUML(t, strings.TrimPrefix(url, prefix)); got != src.String() {
+			t.Errorf("EncodedURL(%q) decodes to %q, want %q", server, got, src.String())
+		}
+	}
+}
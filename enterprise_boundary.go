@@ -7,6 +7,10 @@ import "context"
 type EnterpriseBoundaryArgs struct {
 	// The human-readable name of the enterprise.
 	Name string
+
+	// An optional list of tags classifying the enterprise e.g. "partner".
+	// Diagrams can be filtered by tag using Diagram.Filter.
+	Tags []string
 }
 
 // MustNewEnterpriseBoundary is the same as NewEnterpriseBoundary, but panics on
@@ -25,6 +29,7 @@ func NewEnterpriseBoundary(ctx context.Context, id string, args EnterpriseBounda
 	b := &EnterpriseBoundary{
 		id:   id,
 		name: args.Name,
+		tags: args.Tags,
 	}
 	return b, nil
 }
@@ -35,6 +40,7 @@ type EnterpriseBoundary struct {
 	id       string
 	name     string
 	elements []Element
+	tags     []string
 }
 
 // AddElement adds child elements to the parent EnterpriseBoundary.
@@ -44,3 +50,12 @@ func (eb *EnterpriseBoundary) AddElement(ctx context.Context, el Element) {
 
 // ID satisfies the Element interface.
 func (eb *EnterpriseBoundary) ID() string { return eb.id }
+
+// Name returns the human-readable name of the enterprise.
+func (eb *EnterpriseBoundary) Name() string { return eb.name }
+
+// Tags returns the tags classifying the enterprise.
+func (eb *EnterpriseBoundary) Tags() []string { return eb.tags }
+
+// Elements returns the child elements of the boundary.
+func (eb *EnterpriseBoundary) Elements() []Element { return eb.elements }
@@ -29,11 +29,12 @@ func main() {
 	})
 
 	dn, _ := c4.NewDeploymentNode(ctx, "dn", c4.DeploymentNodeArgs{
-		Name:        "bigbank-api***\tx8",
+		Name:        "bigbank-api***",
 		Type:        "Ubuntu 16.04 LTS",
 		Description: "A web server residing in the web server farm, accessed via F5 BIG-IP LTMs.",
 		Properties:  []c4.Property{{Name: "Location", Value: "London and Reading"}},
 		Elements:    []c4.Element{apache},
+		Instances:   8,
 	})
 
 	db, _ := c4.NewDatabase(ctx, "db", c4.DatabaseArgs{
@@ -42,11 +43,14 @@ func main() {
 		Technologies: []string{"Relational Database Schema"},
 	})
 
+	primaryDB := db.Instance("primaryDB")
+	secondaryDB := db.Instance("secondaryDB")
+
 	oracle, _ := c4.NewDeploymentNode(ctx, "oracle", c4.DeploymentNodeArgs{
 		Name:        "Oracle - Primary",
 		Type:        "Oracle 12c",
 		Description: "The primary, live database server.",
-		Elements:    []c4.Element{db},
+		Elements:    []c4.Element{primaryDB},
 	})
 
 	bigbankdb01, _ := c4.NewDeploymentNode(ctx, "bigbankdb01", c4.DeploymentNodeArgs{
@@ -57,17 +61,11 @@ func main() {
 		Elements:    []c4.Element{oracle},
 	})
 
-	db2, _ := c4.NewDatabase(ctx, "db2", c4.DatabaseArgs{
-		Name:         "Database",
-		Description:  "Stores user registration information, hashed authentication credentials, access logs, etc.",
-		Technologies: []string{"Relational Database Schema"},
-	})
-
 	oracle2, _ := c4.NewDeploymentNode(ctx, "oracle2", c4.DeploymentNodeArgs{
 		Name:        "Oracle - Secondary",
 		Type:        "Oracle 12c",
 		Description: "A secondary, standby database server, used for failover purposes only.",
-		Elements:    []c4.Element{db2},
+		Elements:    []c4.Element{secondaryDB},
 	})
 
 	bigbankdb02, _ := c4.NewDeploymentNode(ctx, "bigbankdb02", c4.DeploymentNodeArgs{
@@ -97,18 +95,25 @@ func main() {
 	})
 
 	bb2, _ := c4.NewDeploymentNode(ctx, "bb2", c4.DeploymentNodeArgs{
-		Name:        "bigbank-web***\tx4",
+		Name:        "bigbank-web***",
 		Type:        "Ubuntu 16.04 LTS",
 		Description: "A web server residing in the web server farm, accessed via F5 BIG-IP LTMs.",
 		Properties:  []c4.Property{{Name: "Location", Value: "London and Reading"}},
 		Elements:    []c4.Element{apache2},
+		Instances:   4,
+	})
+
+	lb, _ := c4.NewInfrastructureNode(ctx, "lb", c4.InfrastructureNodeArgs{
+		Name:         "Load Balancer",
+		Description:  "Distributes incoming requests across the web server farm.",
+		Technologies: []string{"F5 BIG-IP LTM"},
 	})
 
 	plc, _ := c4.NewDeploymentNode(ctx, "plc", c4.DeploymentNodeArgs{
 		Name:        "Live",
 		Type:        "Big Bank plc",
 		Description: "Big bank plc data center",
-		Elements:    []c4.Element{dn, bigbankdb01, bigbankdb02, bb2},
+		Elements:    []c4.Element{lb, dn, bigbankdb01, bigbankdb02, bb2},
 	})
 
 	mobile, _ := c4.NewContainer(ctx, "mobile", c4.ContainerArgs{
@@ -141,7 +146,7 @@ func main() {
 		Elements: []c4.Element{browser},
 	})
 
-	d, _ := c4.NewDiagram(ctx, "Deployment Diagram")
+	d, _ := c4.NewDiagram(ctx, "Deployment Diagram", c4.WithInstanceRelations())
 
 	d.AddElement(ctx, plc)
 	d.AddElement(ctx, mob)
@@ -165,6 +170,15 @@ func main() {
 		},
 		c4.WithDirection(c4.DirectionDown),
 	)
+	d.NewRelation(ctx,
+		c4.RelationArgs{
+			Src:          lb,
+			Dst:          api,
+			Description:  "Forwards requests to",
+			Technologies: []string{"HTTPS"},
+		},
+		c4.WithDirection(c4.DirectionDown),
+	)
 	d.NewRelation(ctx,
 		c4.RelationArgs{
 			Src:         web,
@@ -184,17 +198,8 @@ func main() {
 	)
 	d.NewRelation(ctx,
 		c4.RelationArgs{
-			Src:          api,
-			Dst:          db2,
-			Description:  "Reads from and writes to",
-			Technologies: []string{"JDBC"},
-		},
-		c4.WithDirection(c4.DirectionDown),
-	)
-	d.NewRelation(ctx,
-		c4.RelationArgs{
-			Src:         db,
-			Dst:         db2,
+			Src:         primaryDB,
+			Dst:         secondaryDB,
 			Description: "Replicates data to",
 		},
 		c4.WithDirection(c4.DirectionRight),
@@ -0,0 +1,169 @@
+package c4
+
+import (
+	"context"
+	"fmt"
+)
+
+// FilterOption selects the elements kept by Diagram.Filter.
+type FilterOption func(*filter)
+
+type filter struct {
+	includeTags     []string
+	excludeTags     []string
+	excludeExternal bool
+	types           []Kind
+}
+
+// IncludeTags keeps only the elements with at least one of the given tags.
+func IncludeTags(tags ...string) FilterOption {
+	return func(f *filter) {
+		f.includeTags = append(f.includeTags, tags...)
+	}
+}
+
+// ExcludeTags removes the elements with any of the given tags, along with the
+// elements within them.
+func ExcludeTags(tags ...string) FilterOption {
+	return func(f *filter) {
+		f.excludeTags = append(f.excludeTags, tags...)
+	}
+}
+
+// ExcludeExternal removes external elements, along with the elements within
+// them.
+func ExcludeExternal() FilterOption {
+	return func(f *filter) {
+		f.excludeExternal = true
+	}
+}
+
+// OnlyTypes keeps only the elements of the given kinds. Boundaries match the
+// kind of the element they were created from e.g. KindSystem, and instances
+// match both KindInstance and the kind of the element they are an instance of.
+func OnlyTypes(kinds ...Kind) FilterOption {
+	return func(f *filter) {
+		f.types = append(f.types, kinds...)
+	}
+}
+
+// Filter returns a new diagram containing only the elements selected by the
+// given options, along with the relations between them. This makes it
+// possible to produce several views of a single diagram, such as one without
+// any external systems, without listing the elements of each.
+//
+//	d, err := all.Filter(ctx, c4.IncludeTags("pci"), c4.ExcludeExternal())
+//
+// Excluding an element also excludes the elements within it. Boundaries and
+// deployment nodes that don't match the options themselves are kept while
+// they still contain other elements, and are removed once they are left empty.
+// The new diagram has the same title and options as the original.
+func (d *Diagram) Filter(ctx context.Context, opts ...FilterOption) (*Diagram, error) {
+	if err := d.Validate(ctx); err != nil {
+		return nil, err
+	}
+
+	f := &filter{}
+	for _, opt := range opts {
+		opt(f)
+	}
+	for _, kind := range f.types {
+		if kind == "" || contains([]Kind{KindContainerBoundary, KindDatabaseBoundary, KindSystemBoundary}, kind) {
+			return nil, fmt.Errorf("filter: invalid type %q", kind)
+		}
+	}
+
+	return d.derive(func(el Element, group []Element) bool {
+		if f.excludes(el) {
+			return false
+		}
+		if len(group) > 0 {
+			return true
+		}
+		// Elements that contained others before filtering are only kept when
+		// they were asked for explicitly.
+		if len(children(el)) > 0 && len(f.includeTags) == 0 && len(f.types) == 0 {
+			return false
+		}
+		return f.includes(el)
+	}), nil
+}
+
+// excludes reports whether el, and everything within it, is removed.
+func (f *filter) excludes(el Element) bool {
+	if f.excludeExternal && summarize(el).external {
+		return true
+	}
+	return hasTag(tagsOf(el), f.excludeTags)
+}
+
+// includes reports whether el matches every inclusion option.
+func (f *filter) includes(el Element) bool {
+	if len(f.includeTags) > 0 && !hasTag(tagsOf(el), f.includeTags) {
+		return false
+	}
+	if len(f.types) == 0 {
+		return true
+	}
+	kinds := []Kind{KindOf(el)}
+	switch v := el.(type) {
+	case *containerBoundary:
+		kinds = []Kind{KindContainer}
+	case *databaseBoundary:
+		kinds = []Kind{KindDatabase}
+	case *Instance:
+		kinds = append(kinds, KindOf(v.Of()))
+	case *systemBoundary:
+		kinds = []Kind{KindSystem}
+	}
+	for _, kind := range kinds {
+		if contains(f.types, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// tagsOf returns the tags of el. Instances have the tags of the element they
+// are an instance of.
+func tagsOf(el Element) []string {
+	switch v := el.(type) {
+	case *Component:
+		return v.tags
+	case *Container:
+		return v.tags
+	case *containerBoundary:
+		return v.tags
+	case *Database:
+		return v.tags
+	case *databaseBoundary:
+		return v.tags
+	case *DeploymentNode:
+		return v.tags
+	case *EnterpriseBoundary:
+		return v.tags
+	case *InfrastructureNode:
+		return v.tags
+	case *Instance:
+		return tagsOf(v.Of())
+	case *Person:
+		return v.tags
+	case *Queue:
+		return v.tags
+	case *System:
+		return v.tags
+	case *systemBoundary:
+		return v.tags
+	}
+	return nil
+}
+
+// hasTag reports whether tags contains any of want.
+func hasTag(tags, want []string) bool {
+	for _, tag := range tags {
+		if contains(want, tag) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package c4
+
+import (
+	"context"
+	"fmt"
+)
+
+// FocusOption limits the relations followed by Diagram.Focus. By default,
+// relations are followed in both directions.
+type FocusOption func(*focus)
+
+type focus struct {
+	inbound  bool
+	outbound bool
+}
+
+// FocusInbound follows relations towards the focused element, showing the
+// elements that depend on it.
+func FocusInbound() FocusOption {
+	return func(f *focus) {
+		f.inbound = true
+	}
+}
+
+// FocusOutbound follows relations away from the focused element, showing the
+// elements it depends on.
+func FocusOutbound() FocusOption {
+	return func(f *focus) {
+		f.outbound = true
+	}
+}
+
+// Focus returns a new diagram containing only el and the elements within depth
+// relations of it, along with the relations between them. If el is a boundary
+// or deployment node, the elements within it are included too. The boundaries
+// and deployment nodes enclosing those elements are kept so that they are
+// grouped in the same way as the original diagram. This makes it possible to
+// produce a readable view of each service from a single large diagram.
+//
+//	d, err := all.Focus(ctx, api, 1, c4.FocusInbound())
+//
+// The new diagram has the same title and options as the original.
+func (d *Diagram) Focus(ctx context.Context, el Element, depth int, opts ...FocusOption) (*Diagram, error) {
+	if err := d.Validate(ctx); err != nil {
+		return nil, err
+	}
+	if depth < 0 {
+		return nil, fmt.Errorf("focus: invalid depth %d: depth must not be negative", depth)
+	}
+
+	f := &focus{}
+	for _, opt := range opts {
+		opt(f)
+	}
+	if !f.inbound && !f.outbound {
+		f.inbound, f.outbound = true, true
+	}
+
+	// Focusing on a boundary also focuses on each of the elements within it,
+	// since relations are usually attached to those instead.
+	var frontier []string
+	walk(d.elements, func(e Element) {
+		if e.ID() == el.ID() {
+			frontier = append(frontier, e.ID())
+			walk(children(e), func(child Element) {
+				frontier = append(frontier, child.ID())
+			})
+		}
+	})
+	if len(frontier) == 0 {
+		return nil, fmt.Errorf("focus: %s is not part of the diagram", el.ID())
+	}
+
+	relations := d.renderedRelations()
+	kept := map[string]bool{}
+	for _, id := range frontier {
+		kept[id] = true
+	}
+	for i := 0; i < depth && len(frontier) > 0; i++ {
+		var next []string
+		for _, id := range frontier {
+			for _, rel := range relations {
+				var neighbor string
+				switch {
+				case f.outbound && rel.src.ID() == id:
+					neighbor = rel.dst.ID()
+				case f.inbound && rel.dst.ID() == id:
+					neighbor = rel.src.ID()
+				default:
+					continue
+				}
+				if !kept[neighbor] {
+					kept[neighbor] = true
+					next = append(next, neighbor)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	return d.derive(func(el Element, group []Element) bool {
+		return kept[el.ID()] || len(group) > 0
+	}), nil
+}
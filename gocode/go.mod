@@ -0,0 +1,13 @@
+module github.com/haleyrc/c4/gocode
+
+go 1.22.0
+
+require (
+	github.com/haleyrc/c4 v0.0.0-20261019062658-45d43239f439
+	golang.org/x/tools v0.26.0
+)
+
+require (
+	golang.org/x/mod v0.21.0 // indirect
+	golang.org/x/sync v0.8.0 // indirect
+)
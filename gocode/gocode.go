@@ -0,0 +1,309 @@
+// Package gocode builds c4 components from the structure of Go code.
+//
+// Packages are loaded with golang.org/x/tools/go/packages and converted into a
+// component per package or, when using ModeTypes, a component per annotated
+// type. Relations between components come from the import graph.
+//
+// The generated components can be customized with annotations in doc comments
+// on either the package clause or a type declaration:
+//
+//	// c4:component name="Sign In Controller" tech="net/http" desc="Allows users to sign in."
+//
+// All of the attributes are optional. Packages without an annotation use the
+// package name and the first sentence of the package documentation, while in
+// ModeTypes only annotated types are included.
+//
+//	res, err := gocode.Import(ctx, gocode.ImportArgs{
+//		Dir:      "./services/api",
+//		Patterns: []string{"./..."},
+//		Exclude:  []string{"example.com/api/internal/testutil/..."},
+//		Boundary: apiApplication.Boundary(),
+//	})
+package gocode
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/haleyrc/c4"
+	"github.com/haleyrc/c4/internal/alias"
+)
+
+// Mode controls what is converted into components.
+type Mode int
+
+const (
+	// ModePackages creates a component for every included package.
+	ModePackages Mode = iota
+
+	// ModeTypes creates a component for every type annotated with
+	// c4:component in the included packages.
+	ModeTypes
+)
+
+// DefaultTechnology is used for components without a tech annotation.
+const DefaultTechnology = "Go"
+
+// ImportArgs describes the parameters available for importing Go code.
+type ImportArgs struct {
+	// The directory in which to run the build system. Defaults to the current
+	// directory.
+	Dir string
+
+	// The package patterns to load e.g. "./...". Defaults to "./...".
+	Patterns []string
+
+	// Import path patterns that packages must match to be included. A "..."
+	// matches any string, as with the go command. If empty, every loaded
+	// package is included.
+	Include []string
+
+	// Import path patterns for packages to exclude. Exclusions take precedence
+	// over inclusions.
+	Exclude []string
+
+	// Whether to create components per package or per annotated type.
+	Mode Mode
+
+	// An optional boundary, such as Container.Boundary(), that the components
+	// are added to.
+	Boundary c4.Boundary
+}
+
+// Result holds the elements generated from a set of packages.
+type Result struct {
+	// The generated components in import path order.
+	Components []*c4.Component
+
+	// Relations derived from the imports between included packages.
+	Relations []c4.RelationArgs
+}
+
+// Import loads the packages described by args and converts them into
+// components.
+func Import(ctx context.Context, args ImportArgs) (*Result, error) {
+	patterns := args.Patterns
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	cfg := &packages.Config{
+		Context: ctx,
+		Dir:     args.Dir,
+		Mode:    packages.NeedName | packages.NeedImports | packages.NeedFiles | packages.NeedCompiledGoFiles | packages.NeedSyntax,
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("gocode: import: %w", err)
+	}
+
+	var errs []string
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for _, err := range pkg.Errors {
+			errs = append(errs, err.Error())
+		}
+	})
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("gocode: import: %s", strings.Join(errs, "; "))
+	}
+
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].PkgPath < pkgs[j].PkgPath })
+
+	res := &Result{}
+	byPath := map[string][]*c4.Component{}
+	for _, pkg := range pkgs {
+		if !included(pkg.PkgPath, args.Include, args.Exclude) {
+			continue
+		}
+
+		var components []*c4.Component
+		switch args.Mode {
+		case ModeTypes:
+			components, err = typeComponents(ctx, pkg)
+		default:
+			var c *c4.Component
+			c, err = packageComponent(ctx, pkg)
+			components = []*c4.Component{c}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		byPath[pkg.PkgPath] = components
+		res.Components = append(res.Components, components...)
+		if args.Boundary != nil {
+			for _, c := range components {
+				args.Boundary.AddElement(ctx, c)
+			}
+		}
+	}
+
+	for _, pkg := range pkgs {
+		imports := make([]string, 0, len(pkg.Imports))
+		for path := range pkg.Imports {
+			imports = append(imports, path)
+		}
+		sort.Strings(imports)
+
+		for _, src := range byPath[pkg.PkgPath] {
+			for _, path := range imports {
+				for _, dst := range byPath[path] {
+					res.Relations = append(res.Relations, c4.RelationArgs{
+						Src:         src,
+						Dst:         dst,
+						Description: "Uses",
+					})
+				}
+			}
+		}
+	}
+
+	return res, nil
+}
+
+func packageComponent(ctx context.Context, pkg *packages.Package) (*c4.Component, error) {
+	args := c4.ComponentArgs{
+		Name:         pkg.Name,
+		Technologies: []string{DefaultTechnology},
+	}
+
+	for _, f := range pkg.Syntax {
+		if f.Doc == nil {
+			continue
+		}
+		text := f.Doc.Text()
+		if args.Description == "" {
+			args.Description = synopsis(text)
+		}
+		if attrs, ok := parseAnnotation(text); ok {
+			applyAnnotation(&args, attrs)
+		}
+	}
+
+	return c4.NewComponent(ctx, alias.Make(pkg.PkgPath), args)
+}
+
+func typeComponents(ctx context.Context, pkg *packages.Package) ([]*c4.Component, error) {
+	var components []*c4.Component
+	for _, f := range pkg.Syntax {
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts, ok := spec.(*ast.TypeSpec)
+				if !ok {
+					continue
+				}
+
+				// A lone type declaration carries its comment on the GenDecl,
+				// while grouped declarations carry it on each spec.
+				cg := ts.Doc
+				if cg == nil && len(gd.Specs) == 1 {
+					cg = gd.Doc
+				}
+				if cg == nil {
+					continue
+				}
+
+				text := cg.Text()
+				attrs, ok := parseAnnotation(text)
+				if !ok {
+					continue
+				}
+
+				args := c4.ComponentArgs{
+					Name:         ts.Name.Name,
+					Description:  synopsis(text),
+					Technologies: []string{DefaultTechnology},
+				}
+				applyAnnotation(&args, attrs)
+
+				c, err := c4.NewComponent(ctx, alias.Make(pkg.PkgPath, ts.Name.Name), args)
+				if err != nil {
+					return nil, err
+				}
+				components = append(components, c)
+			}
+		}
+	}
+	return components, nil
+}
+
+var (
+	annotationRE = regexp.MustCompile(`(?m)^\s*c4:component\b(.*)$`)
+	attributeRE  = regexp.MustCompile(`(\w+)="((?:[^"\\]|\\.)*)"`)
+)
+
+// synopsis returns the first sentence of a doc comment, ignoring any
+// c4:component annotation so that it isn't used as the description.
+func synopsis(text string) string {
+	return doc.Synopsis(annotationRE.ReplaceAllString(text, ""))
+}
+
+// parseAnnotation returns the attributes of the c4:component annotation in a
+// doc comment, if present.
+func parseAnnotation(text string) (map[string]string, bool) {
+	m := annotationRE.FindStringSubmatch(text)
+	if m == nil {
+		return nil, false
+	}
+
+	attrs := map[string]string{}
+	for _, attr := range attributeRE.FindAllStringSubmatch(m[1], -1) {
+		attrs[attr[1]] = strings.ReplaceAll(attr[2], `\"`, `"`)
+	}
+	return attrs, true
+}
+
+func applyAnnotation(args *c4.ComponentArgs, attrs map[string]string) {
+	if name, ok := attrs["name"]; ok {
+		args.Name = name
+	}
+	if desc, ok := attrs["desc"]; ok {
+		args.Description = desc
+	}
+	if tech, ok := attrs["tech"]; ok {
+		args.Technologies = strings.Split(tech, ",")
+		for i := range args.Technologies {
+			args.Technologies[i] = strings.TrimSpace(args.Technologies[i])
+		}
+	}
+}
+
+func included(path string, include, exclude []string) bool {
+	for _, pattern := range exclude {
+		if match(pattern, path) {
+			return false
+		}
+	}
+	if len(include) == 0 {
+		return true
+	}
+	for _, pattern := range include {
+		if match(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// match reports whether path matches pattern using the go command's rules: a
+// "..." matches any string and a trailing "/..." also matches the parent.
+func match(pattern, path string) bool {
+	re := regexp.QuoteMeta(pattern)
+	re = strings.ReplaceAll(re, `\.\.\.`, `.*`)
+	if strings.HasSuffix(re, `/.*`) {
+		re = strings.TrimSuffix(re, `/.*`) + `(/.*)?`
+	}
+	matched, _ := regexp.MatchString("^"+re+"$", path)
+	return matched
+}
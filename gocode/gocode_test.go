@@ -0,0 +1,137 @@
+package gocode
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"example.com/shop", "example.com/shop", true},
+		{"example.com/shop", "example.com/shop/api", false},
+		{"example.com/shop/...", "example.com/shop", true},
+		{"example.com/shop/...", "example.com/shop/api", true},
+		{"example.com/shop/...", "example.com/shopping", false},
+		{"example.com/.../internal/...", "example.com/shop/internal/testutil", true},
+		{"example.com/.../internal/...", "example.com/shop/api", false},
+		{"example.com/shop/...util", "example.com/shop/internal/testutil", true},
+		{"example.com/shop.v2", "example.com/shopxv2", false},
+	}
+	for _, tt := range tests {
+		if got := match(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("match(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestParseAnnotation(t *testing.T) {
+	tests := []struct {
+		text  string
+		attrs map[string]string
+		ok    bool
+	}{
+		{"Package api serves the API.\n", nil, false},
+		{"c4:component\n", map[string]string{}, true},
+		{"Handler serves orders.\n\nc4:component name=\"Orders\" tech=\"net/http\"\n", map[string]string{"name": "Orders", "tech": "net/http"}, true},
+		{"  c4:component desc=\"Serves \\\"orders\\\".\"\n", map[string]string{"desc": `Serves "orders".`}, true},
+		{"c4:componentized\n", nil, false},
+		{"See the c4:component annotation.\n", nil, false},
+	}
+	for _, tt := range tests {
+		attrs, ok := parseAnnotation(tt.text)
+		if ok != tt.ok || len(attrs) != len(tt.attrs) {
+			t.Errorf("parseAnnotation(%q) = %v, %v, want %v, %v", tt.text, attrs, ok, tt.attrs, tt.ok)
+			continue
+		}
+		for k, v := range tt.attrs {
+			if attrs[k] != v {
+				t.Errorf("parseAnnotation(%q) = %v, want %v", tt.text, attrs, tt.attrs)
+				break
+			}
+		}
+	}
+}
+
+func TestImport(t *testing.T) {
+	// The test package is a module of its own, which isn't part of the
+	// workspace.
+	t.Setenv("GOWORK", "off")
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		args       ImportArgs
+		components []string
+		relations  []string
+	}{
+		{
+			name: "packages",
+			args: ImportArgs{Exclude: []string{"example.com/shop/internal/..."}},
+			components: []string{
+				"example_com_shop_api|Shop API|Package api serves the shop over HTTP.|net/http, JSON",
+				"example_com_shop_orders|orders|Package orders manages orders.|Go",
+				"example_com_shop_store|store|Package store persists orders.|Go",
+			},
+			relations: []string{
+				"example_com_shop_api -> example_com_shop_orders",
+				"example_com_shop_orders -> example_com_shop_store",
+			},
+		},
+		{
+			name: "included packages",
+			args: ImportArgs{Patterns: []string{"./api", "./orders", "./store"}, Include: []string{"example.com/shop/orders", "example.com/shop/store"}},
+			components: []string{
+				"example_com_shop_orders|orders|Package orders manages orders.|Go",
+				"example_com_shop_store|store|Package store persists orders.|Go",
+			},
+			relations: []string{
+				"example_com_shop_orders -> example_com_shop_store",
+			},
+		},
+		{
+			name: "types",
+			args: ImportArgs{Mode: ModeTypes},
+			components: []string{
+				`example_com_shop_api_Handler|Handler|Serves "orders" as JSON.|Go`,
+				"example_com_shop_orders_Service|Order Service|Service places and cancels orders.|Go",
+				"example_com_shop_store_Store|Store|Store saves orders in memory.|Go, sync.Map",
+			},
+			relations: []string{
+				"example_com_shop_api_Handler -> example_com_shop_orders_Service",
+				"example_com_shop_orders_Service -> example_com_shop_store_Store",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			args.Dir = filepath.Join("testdata", "shop")
+			res, err := Import(ctx, args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var components []string
+			for _, c := range res.Components {
+				components = append(components, strings.Join([]string{c.ID(), c.Name(), c.Description(), strings.Join(c.Technologies(), ", ")}, "|"))
+			}
+			if got, want := strings.Join(components, "\n"), strings.Join(tt.components, "\n"); got != want {
+				t.Errorf("got components:\n%s\nwant:\n%s", got, want)
+			}
+
+			var relations []string
+			for _, rel := range res.Relations {
+				relations = append(relations, rel.Src.ID()+" -> "+rel.Dst.ID())
+			}
+			if got, want := strings.Join(relations, "\n"), strings.Join(tt.relations, "\n"); got != want {
+				t.Errorf("got relations:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+// Package api serves the shop over HTTP. It is the only package that knows
+// about requests and responses.
+//
+// c4:component name="Shop API" tech="net/http, JSON"
+package api
+
+import (
+	"example.com/shop/internal/testutil"
+	"example.com/shop/orders"
+)
+
+// Handler serves requests for orders.
+//
+// c4:component desc="Serves \"orders\" as JSON."
+type Handler struct {
+	Orders *orders.Service
+}
+
+// Health isn't annotated, so it isn't a component.
+type Health struct{}
+
+var _ = testutil.Fixture
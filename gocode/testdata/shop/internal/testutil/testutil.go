@@ -0,0 +1,5 @@
+// Package testutil holds helpers that shouldn't appear in diagrams.
+package testutil
+
+// Fixture is used to give api an import to exclude.
+const Fixture = "fixture"
@@ -0,0 +1,15 @@
+// Package orders manages orders.
+package orders
+
+import "example.com/shop/store"
+
+type (
+	// Service places and cancels orders.
+	// c4:component name="Order Service"
+	Service struct {
+		Store *store.Store
+	}
+
+	// Order is a single order.
+	Order struct{}
+)
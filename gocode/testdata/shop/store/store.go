@@ -0,0 +1,7 @@
+// Package store persists orders.
+package store
+
+// Store saves orders in memory.
+//
+// c4:component tech="Go, sync.Map"
+type Store struct{}
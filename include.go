@@ -0,0 +1,241 @@
+package c4
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// EmbeddedC4Version is the C4-PlantUML release that is embedded in this
+// package and used by the WithEmbeddedC4 option.
+const EmbeddedC4Version = "v2.8.0"
+
+const c4RemoteBase = "https://raw.githubusercontent.com/plantuml-stdlib/C4-PlantUML"
+
+type includeMode int
+
+const (
+	includeRemote includeMode = iota
+	includeStdlib
+	includeDirectory
+	includeEmbedded
+)
+
+//go:embed c4plantuml
+var embeddedC4 embed.FS
+
+// C4Library identifies one of the files making up the C4-PlantUML library. Each
+// library builds on the previous level, so including C4Component also makes
+// everything in C4Container and C4Context available.
+type C4Library string
+
+const (
+	C4Context    C4Library = "C4_Context"
+	C4Container  C4Library = "C4_Container"
+	C4Component  C4Library = "C4_Component"
+	C4Deployment C4Library = "C4_Deployment"
+	C4Dynamic    C4Library = "C4_Dynamic"
+	C4Sequence   C4Library = "C4_Sequence"
+
+	// The common base included by every other library.
+	c4Base C4Library = "C4"
+)
+
+// c4Libraries lists the libraries in the order they should be included.
+var c4Libraries = []C4Library{C4Context, C4Container, C4Component, C4Deployment, C4Dynamic, C4Sequence}
+
+// c4Dependencies lists the C4-PlantUML files each file includes itself. This is
+// used to avoid including a library that another included library already
+// provides, and to inline the embedded library in the correct order.
+var c4Dependencies = map[C4Library][]C4Library{
+	c4Base:       nil,
+	C4Context:    {c4Base},
+	C4Container:  {C4Context},
+	C4Component:  {C4Container},
+	C4Deployment: {C4Container},
+	C4Dynamic:    {C4Component},
+	C4Sequence:   {C4Component},
+}
+
+// requiredLibraries returns the smallest set of libraries that defines every
+// element in els. Libraries that are already included by another library in
+// the set are omitted.
+func requiredLibraries(els []Element) []C4Library {
+	needed := map[C4Library]bool{}
+	walk(els, func(el Element) {
+		switch el.(type) {
+		case *Person, *System, *systemBoundary, *EnterpriseBoundary:
+			needed[C4Context] = true
+		case *Container, *Database, *Queue, *containerBoundary, *databaseBoundary:
+			needed[C4Container] = true
+		case *Component:
+			needed[C4Component] = true
+		case *DeploymentNode, *InfrastructureNode, *Instance:
+			needed[C4Deployment] = true
+		}
+	})
+
+	for lib := range needed {
+		for dep := range transitiveDependencies(lib) {
+			delete(needed, dep)
+		}
+	}
+
+	var libs []C4Library
+	for _, lib := range c4Libraries {
+		if needed[lib] {
+			libs = append(libs, lib)
+		}
+	}
+	if len(libs) == 0 {
+		libs = append(libs, C4Context)
+	}
+	return libs
+}
+
+func transitiveDependencies(lib C4Library) map[C4Library]bool {
+	deps := map[C4Library]bool{}
+	var visit func(lib C4Library)
+	visit = func(lib C4Library) {
+		for _, dep := range c4Dependencies[lib] {
+			deps[dep] = true
+			visit(dep)
+		}
+	}
+	visit(lib)
+	return deps
+}
+
+// writeIncludes writes the statements necessary to load the given C4-PlantUML
+// libraries according to the configured include mode.
+func (d *Diagram) writeIncludes(buff *bytes.Buffer, libs []C4Library) error {
+	switch d.includeMode {
+	case includeStdlib:
+		for _, lib := range libs {
+			fmt.Fprintf(buff, "!include <C4/%s>\n", lib)
+		}
+	case includeDirectory:
+		// The C4-PlantUML files include each other using RELATIVE_INCLUDE if
+		// it is defined, which keeps the whole library coming from the same
+		// location.
+		fmt.Fprintf(buff, "!RELATIVE_INCLUDE = \"%s\"\n", d.includeLocation)
+		for _, lib := range libs {
+			fmt.Fprintf(buff, "!include %s/%s.puml\n", d.includeLocation, lib)
+		}
+	case includeEmbedded:
+		return writeEmbeddedC4(buff, embeddedC4, libs)
+	default:
+		version := d.includeLocation
+		if version == "" {
+			version = "master"
+		} else {
+			fmt.Fprintf(buff, "!RELATIVE_INCLUDE = \"%s/%s\"\n", c4RemoteBase, version)
+		}
+		for _, lib := range libs {
+			fmt.Fprintf(buff, "!include %s/%s/%s.puml\n", c4RemoteBase, version, lib)
+		}
+	}
+	return nil
+}
+
+// writeEmbeddedC4 inlines the copy of the given libraries in the c4plantuml
+// directory of fsys, along with their dependencies. Since every dependency is
+// written out explicitly, the include statements within the library files are
+// dropped. This also removes the fallback includes of the upstream master
+// branch, so the output never loads anything remotely.
+func writeEmbeddedC4(w io.Writer, fsys fs.FS, libs []C4Library) error {
+	written := map[C4Library]bool{}
+
+	var write func(lib C4Library) error
+	write = func(lib C4Library) error {
+		if written[lib] {
+			return nil
+		}
+		written[lib] = true
+
+		for _, dep := range c4Dependencies[lib] {
+			if err := write(dep); err != nil {
+				return err
+			}
+		}
+
+		src, err := fs.ReadFile(fsys, path.Join("c4plantuml", string(lib)+".puml"))
+		if err != nil {
+			return fmt.Errorf("cannot include embedded %s: %w", lib, err)
+		}
+
+		s := bufio.NewScanner(bytes.NewReader(src))
+		for s.Scan() {
+			line := s.Text()
+			if strings.HasPrefix(strings.TrimSpace(line), "!include") {
+				continue
+			}
+			fmt.Fprintln(w, line)
+		}
+		return s.Err()
+	}
+
+	for _, lib := range libs {
+		if err := write(lib); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WithC4Version pins the C4-PlantUML library to a release tag e.g. "v2.8.0"
+// rather than following the master branch. This keeps the resultant diagrams
+// stable when the upstream library changes.
+func WithC4Version(tag string) DiagramOption {
+	return func(d *Diagram) {
+		d.includeMode = includeRemote
+		d.includeLocation = tag
+	}
+}
+
+// WithC4Stdlib includes the copy of C4-PlantUML bundled with PlantUML's
+// standard library e.g. <C4/C4_Container>. This doesn't require network access
+// when rendering, but the version depends on the PlantUML installation.
+func WithC4Stdlib() DiagramOption {
+	return func(d *Diagram) {
+		d.includeMode = includeStdlib
+		d.includeLocation = ""
+	}
+}
+
+// WithC4Directory includes the C4-PlantUML library from a local directory
+// containing the .puml files. The directory is resolved by PlantUML, so a
+// relative path is relative to the location of the PlantUML specification.
+func WithC4Directory(dir string) DiagramOption {
+	return func(d *Diagram) {
+		d.includeMode = includeDirectory
+		d.includeLocation = strings.TrimSuffix(dir, "/")
+	}
+}
+
+// WithEmbeddedC4 writes the copy of C4-PlantUML embedded in this package
+// (EmbeddedC4Version) directly into the resultant PlantUML. The output is
+// larger, but completely self-contained.
+func WithEmbeddedC4() DiagramOption {
+	return func(d *Diagram) {
+		d.includeMode = includeEmbedded
+		d.includeLocation = ""
+	}
+}
+
+// WithC4Libraries overrides the set of C4-PlantUML libraries included in the
+// resultant PlantUML. By default, only the libraries required by the elements
+// in the diagram are included. This can be used to include libraries that
+// can't be inferred, such as C4Dynamic, or to make the includes consistent
+// across a set of diagrams.
+func WithC4Libraries(libs ...C4Library) DiagramOption {
+	return func(d *Diagram) {
+		d.libraries = libs
+	}
+}
@@ -0,0 +1,112 @@
+package c4
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// c4Fixture returns a stand-in for a C4-PlantUML file which, like the upstream
+// files, includes its dependency relative to RELATIVE_INCLUDE if it is defined
+// and from the master branch otherwise.
+func c4Fixture(lib C4Library, deps ...C4Library) *fstest.MapFile {
+	var b strings.Builder
+	for _, dep := range deps {
+		b.WriteString("!if %variable_exists(\"RELATIVE_INCLUDE\")\n")
+		b.WriteString("  !include %get_variable_value(\"RELATIVE_INCLUDE\")/" + string(dep) + ".puml\n")
+		b.WriteString("!else\n")
+		b.WriteString("  !include " + c4RemoteBase + "/master/" + string(dep) + ".puml\n")
+		b.WriteString("!endif\n")
+	}
+	b.WriteString("' " + string(lib) + "\n")
+	return &fstest.MapFile{Data: []byte(b.String())}
+}
+
+// assertNoIncludes fails if src includes anything, either remotely or from
+// the local file system.
+func assertNoIncludes(t *testing.T, src string) {
+	t.Helper()
+	for _, line := range strings.Split(src, "\n") {
+		if strings.Contains(line, "!include") {
+			t.Errorf("got include %q, want the library inlined", strings.TrimSpace(line))
+		}
+	}
+}
+
+func TestWriteEmbeddedC4(t *testing.T) {
+	fsys := fstest.MapFS{}
+	for lib, deps := range c4Dependencies {
+		fsys["c4plantuml/"+string(lib)+".puml"] = c4Fixture(lib, deps...)
+	}
+
+	tests := []struct {
+		libs []C4Library
+		want []C4Library
+	}{
+		{[]C4Library{C4Context}, []C4Library{c4Base, C4Context}},
+		{[]C4Library{C4Component}, []C4Library{c4Base, C4Context, C4Container, C4Component}},
+		{[]C4Library{C4Deployment, C4Dynamic}, []C4Library{c4Base, C4Context, C4Container, C4Deployment, C4Component, C4Dynamic}},
+	}
+	for _, tt := range tests {
+		var buff bytes.Buffer
+		if err := writeEmbeddedC4(&buff, fsys, tt.libs); err != nil {
+			t.Fatalf("writeEmbeddedC4(%v): %v", tt.libs, err)
+		}
+		src := buff.String()
+		assertNoIncludes(t, src)
+
+		var got []C4Library
+		for _, line := range strings.Split(src, "\n") {
+			if strings.HasPrefix(line, "' ") {
+				got = append(got, C4Library(strings.TrimPrefix(line, "' ")))
+			}
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("writeEmbeddedC4(%v) wrote %v, want %v", tt.libs, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("writeEmbeddedC4(%v) wrote %v, want %v", tt.libs, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestWriteEmbeddedC4Missing(t *testing.T) {
+	fsys := fstest.MapFS{"c4plantuml/C4.puml": c4Fixture(c4Base)}
+	err := writeEmbeddedC4(&bytes.Buffer{}, fsys, []C4Library{C4Context})
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("got error %v, want %v", err, fs.ErrNotExist)
+	}
+	if !strings.Contains(err.Error(), string(C4Context)) {
+		t.Errorf("got error %q, want it to name %s", err, C4Context)
+	}
+}
+
+func TestWithEmbeddedC4(t *testing.T) {
+	if _, err := fs.Stat(embeddedC4, "c4plantuml/C4.puml"); err != nil {
+		t.Skipf("C4-PlantUML %s isn't vendored, run make c4plantuml", EmbeddedC4Version)
+	}
+	if _, err := fs.Stat(embeddedC4, "c4plantuml/LICENSE"); err != nil {
+		t.Errorf("C4-PlantUML is vendored without its license: %v", err)
+	}
+
+	ctx := context.Background()
+	d, err := NewDiagram(ctx, "Embedded", WithEmbeddedC4(), WithC4Libraries(c4Libraries...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.AddElement(ctx, MustNewPerson(ctx, "customer", PersonArgs{Name: "Customer"}))
+
+	var buff bytes.Buffer
+	if err := d.PlantUML(ctx, &buff); err != nil {
+		t.Fatalf("PlantUML: %v", err)
+	}
+	assertNoIncludes(t, buff.String())
+}
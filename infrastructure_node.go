@@ -0,0 +1,94 @@
+package c4
+
+import (
+	"context"
+)
+
+// InfrastructureNodeArgs describes the parameters available for configuring an
+// infrastructure node.
+type InfrastructureNodeArgs struct {
+	// The human-readable name of the infrastructure node.
+	Name string
+
+	// A general description of the purpose of the infrastructure node.
+	Description string
+
+	// An optional list of technologies describing the infrastructure node e.g.
+	// F5 BIG-IP LTM.
+	Technologies []string
+
+	// An optional sprite to display on the infrastructure node e.g.
+	// "aws/NetworkingContentDelivery/ElasticLoadBalancing". See SpriteLibrary
+	// for the available libraries.
+	Sprite string
+
+	// An optional list of properties describing the infrastructure node. These
+	// are displayed as a table within the infrastructure node.
+	Properties []Property
+
+	// An optional list of tags classifying the infrastructure node e.g. "pci".
+	// Diagrams can be filtered by tag using Diagram.Filter.
+	Tags []string
+}
+
+// MustNewInfrastructureNode is the same as NewInfrastructureNode, but panics on
+// any error.
+func MustNewInfrastructureNode(ctx context.Context, id string, args InfrastructureNodeArgs) *InfrastructureNode {
+	n, err := NewInfrastructureNode(ctx, id, args)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+// NewInfrastructureNode constructs an infrastructure node that can be used in a
+// Diagram.
+func NewInfrastructureNode(ctx context.Context, id string, args InfrastructureNodeArgs) (*InfrastructureNode, error) {
+	n := &InfrastructureNode{
+		id:           id,
+		name:         args.Name,
+		description:  args.Description,
+		technologies: args.Technologies,
+		sprite:       args.Sprite,
+		properties:   args.Properties,
+		tags:         args.Tags,
+	}
+	return n, nil
+}
+
+// InfrastructureNode represents a C4 infrastructure node
+// (https://c4model.com/#DeploymentDiagram), which the C4 documentation
+// describes as supporting infrastructure that isn't a deployed container e.g.
+// DNS services, load balancers, firewalls, etc. Infrastructure nodes are
+// usually added to a DeploymentNode and can take part in relations like any
+// other element.
+type InfrastructureNode struct {
+	id           string
+	name         string
+	description  string
+	technologies []string
+	sprite       string
+	tags         []string
+	properties   []Property
+}
+
+// ID satisfies the Element interface.
+func (n *InfrastructureNode) ID() string { return n.id }
+
+// Name returns the human-readable name of the node.
+func (n *InfrastructureNode) Name() string { return n.name }
+
+// Description returns the description of the node.
+func (n *InfrastructureNode) Description() string { return n.description }
+
+// Technologies returns the technologies describing the node.
+func (n *InfrastructureNode) Technologies() []string { return n.technologies }
+
+// Sprite returns the sprite displayed on the node, if any.
+func (n *InfrastructureNode) Sprite() string { return n.sprite }
+
+// Properties returns the properties describing the node.
+func (n *InfrastructureNode) Properties() []Property { return n.properties }
+
+// Tags returns the tags classifying the infrastructure node.
+func (n *InfrastructureNode) Tags() []string { return n.tags }
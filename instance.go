@@ -0,0 +1,95 @@
+package c4
+
+// Instance represents a deployed copy of a container, database or queue within
+// a deployment node. An instance shares the name, description, technologies,
+// etc. of the element it was created from, but has its own identifier so that
+// the same logical element can appear in multiple deployment nodes.
+//
+// Instances are only valid within a DeploymentNode. Relations declared on the
+// original element can be copied onto its instances using the
+// WithInstanceRelations option.
+type Instance struct {
+	id string
+	of Element
+}
+
+// ID satisfies the Element interface.
+func (i *Instance) ID() string { return i.id }
+
+// Of returns the element that i is an instance of.
+func (i *Instance) Of() Element { return i.of }
+
+// Instance returns a deployment instance of the container with the given
+// identifier.
+func (c *Container) Instance(id string) *Instance {
+	return &Instance{id: id, of: c}
+}
+
+// Instance returns a deployment instance of the database with the given
+// identifier.
+func (db *Database) Instance(id string) *Instance {
+	return &Instance{id: id, of: db}
+}
+
+// Instance returns a deployment instance of the queue with the given
+// identifier.
+func (q *Queue) Instance(id string) *Instance {
+	return &Instance{id: id, of: q}
+}
+
+// element returns a copy of the original element using the identifier of the
+// instance.
+func (i *Instance) element() Element {
+	switch v := i.of.(type) {
+	case *Container:
+		c := *v
+		c.id = i.id
+		return &c
+	case *Database:
+		db := *v
+		db.id = i.id
+		return &db
+	case *Queue:
+		q := *v
+		q.id = i.id
+		return &q
+	}
+	return i.of
+}
+
+// expandInstanceRelations copies each relation involving an element with
+// instances in the diagram onto those instances. The original relation is only
+// kept if the element itself is also part of the diagram.
+func (d *Diagram) expandInstanceRelations() []*relation {
+	// Elements are matched by ID, since an element may be part of the diagram
+	// as a boundary created from it rather than the element itself.
+	present := map[string]bool{}
+	instances := map[string][]Element{}
+	walk(d.elements, func(el Element) {
+		present[el.ID()] = true
+		if i, ok := el.(*Instance); ok {
+			instances[i.of.ID()] = append(instances[i.of.ID()], i)
+		}
+	})
+
+	endpoints := func(el Element) []Element {
+		els := instances[el.ID()]
+		if len(els) == 0 || present[el.ID()] {
+			els = append([]Element{el}, els...)
+		}
+		return els
+	}
+
+	var rels []*relation
+	for _, rel := range d.relations {
+		for _, src := range endpoints(rel.src) {
+			for _, dst := range endpoints(rel.dst) {
+				r := *rel
+				r.src = src
+				r.dst = dst
+				rels = append(rels, &r)
+			}
+		}
+	}
+	return rels
+}
@@ -0,0 +1,37 @@
+// Package alias builds element identifiers from arbitrary names for use by the
+// importers. PlantUML aliases may only contain letters, digits and
+// underscores, so any other characters are replaced.
+package alias
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Make joins parts into an identifier that is safe to use as a PlantUML alias
+// e.g. Make("default", "web-api") returns "default_web_api". Empty parts are
+// skipped.
+func Make(parts ...string) string {
+	var b strings.Builder
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte('_')
+		}
+		for _, r := range part {
+			if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+				b.WriteRune(r)
+			} else {
+				b.WriteByte('_')
+			}
+		}
+	}
+
+	id := b.String()
+	if id == "" || unicode.IsDigit(rune(id[0])) {
+		id = "_" + id
+	}
+	return id
+}
@@ -0,0 +1,362 @@
+// Package k8s builds c4 deployment nodes from Kubernetes manifests.
+//
+// Namespaces, Deployments, StatefulSets, Services and Ingresses are read from
+// YAML or JSON manifests and converted into a tree of deployment nodes: one
+// node per namespace containing a node per workload. Services and Ingresses
+// become infrastructure nodes within their namespace, related to the workloads
+// they route traffic to.
+//
+// Workloads are attached to existing c4 elements using the Elements mapping in
+// ImportArgs, which means the same container definitions used in container
+// diagrams can be reused in the deployment diagram generated from the cluster
+// manifests. Containers, databases and queues are deployed as instances with
+// an identifier qualified by the namespace and workload, so relations between
+// them are shown when the diagram uses c4.WithInstanceRelations:
+//
+//	res, err := k8s.Import(ctx, k8s.ImportArgs{
+//		Elements: map[string]c4.Element{
+//			"banking/api": api,
+//			"banking/web": web,
+//		},
+//	}, "./deploy")
+//
+//	for _, n := range res.Nodes {
+//		d.AddElement(ctx, n)
+//	}
+//	for _, rel := range res.Relations {
+//		d.NewRelation(ctx, rel)
+//	}
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/haleyrc/c4"
+	"github.com/haleyrc/c4/internal/alias"
+)
+
+// DefaultNamespace is the namespace used for objects that don't specify one.
+const DefaultNamespace = "default"
+
+// ImportArgs describes the parameters available for importing manifests.
+type ImportArgs struct {
+	// Maps workloads to the c4 elements that are deployed by them. Keys are
+	// either "namespace/name" or just the name of the workload, with the
+	// former taking precedence. Containers, databases and queues are
+	// deployed as instances, so one element may be mapped to workloads in
+	// several namespaces. Workloads without a mapping are imported as empty
+	// deployment nodes.
+	Elements map[string]c4.Element
+}
+
+// Result holds the elements generated from a set of manifests.
+type Result struct {
+	// One deployment node per namespace in the order they were first seen.
+	Nodes []*c4.DeploymentNode
+
+	// Relations from Ingresses to Services and from Services to the
+	// workloads they select.
+	Relations []c4.RelationArgs
+}
+
+// Import reads the manifests at the given paths and converts them into
+// deployment nodes. Paths may be files or directories, which are searched
+// recursively for .yaml, .yml and .json files.
+func Import(ctx context.Context, args ImportArgs, paths ...string) (*Result, error) {
+	objs, err := readPaths(paths)
+	if err != nil {
+		return nil, fmt.Errorf("k8s: import: %w", err)
+	}
+	return build(ctx, objs, args)
+}
+
+// Decode is the same as Import, but reads the manifests from r.
+func Decode(ctx context.Context, r io.Reader, args ImportArgs) (*Result, error) {
+	objs, err := decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("k8s: decode: %w", err)
+	}
+	return build(ctx, objs, args)
+}
+
+type workload struct {
+	node   *c4.DeploymentNode
+	labels map[string]string
+}
+
+type service struct {
+	node  *c4.InfrastructureNode
+	ports []servicePort
+}
+
+func build(ctx context.Context, objs []object, args ImportArgs) (*Result, error) {
+	res := &Result{}
+
+	namespaces := map[string]*c4.DeploymentNode{}
+	namespace := func(name string) (*c4.DeploymentNode, error) {
+		if name == "" {
+			name = DefaultNamespace
+		}
+		if ns, ok := namespaces[name]; ok {
+			return ns, nil
+		}
+		ns, err := c4.NewDeploymentNode(ctx, alias.Make(name), c4.DeploymentNodeArgs{
+			Name: name,
+			Type: "Kubernetes Namespace",
+		})
+		if err != nil {
+			return nil, err
+		}
+		namespaces[name] = ns
+		res.Nodes = append(res.Nodes, ns)
+		return ns, nil
+	}
+
+	workloads := map[string][]workload{}
+	services := map[string]service{}
+	var ingresses []object
+
+	for _, obj := range objs {
+		switch obj.Kind {
+		case "Namespace":
+			if _, err := namespace(obj.Metadata.Name); err != nil {
+				return nil, err
+			}
+		case "Deployment", "StatefulSet":
+			ns, err := namespace(obj.Metadata.Namespace)
+			if err != nil {
+				return nil, err
+			}
+			n, err := newWorkloadNode(ctx, obj, args)
+			if err != nil {
+				return nil, err
+			}
+			ns.AddElement(ctx, n)
+			workloads[ns.ID()] = append(workloads[ns.ID()], workload{
+				node:   n,
+				labels: obj.Spec.Template.Metadata.Labels,
+			})
+		case "Service":
+			ns, err := namespace(obj.Metadata.Namespace)
+			if err != nil {
+				return nil, err
+			}
+			n, err := c4.NewInfrastructureNode(ctx, alias.Make(ns.ID(), "svc", obj.Metadata.Name), c4.InfrastructureNodeArgs{
+				Name:         obj.Metadata.Name,
+				Description:  serviceType(obj.Spec.Type) + " service",
+				Technologies: []string{"Kubernetes Service"},
+				Properties:   labelProperties(obj.Metadata.Labels),
+			})
+			if err != nil {
+				return nil, err
+			}
+			ns.AddElement(ctx, n)
+			services[ns.ID()+"/"+obj.Metadata.Name] = service{node: n, ports: obj.Spec.Ports}
+		case "Ingress":
+			ingresses = append(ingresses, obj)
+		}
+	}
+
+	// Services are related after every workload has been seen since manifests
+	// frequently declare the service before the deployment it selects.
+	for _, obj := range objs {
+		if obj.Kind != "Service" {
+			continue
+		}
+		ns, err := namespace(obj.Metadata.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		selector := obj.Spec.selector()
+		if len(selector) == 0 {
+			continue
+		}
+		svc := services[ns.ID()+"/"+obj.Metadata.Name]
+		for _, w := range workloads[ns.ID()] {
+			if !matches(selector, w.labels) {
+				continue
+			}
+			res.Relations = append(res.Relations, c4.RelationArgs{
+				Src:          svc.node,
+				Dst:          w.node,
+				Description:  "Routes traffic to",
+				Technologies: portTechnologies(svc.ports),
+			})
+		}
+	}
+
+	for _, obj := range ingresses {
+		ns, err := namespace(obj.Metadata.Namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		var hosts []string
+		for _, rule := range obj.Spec.Rules {
+			if rule.Host != "" {
+				hosts = append(hosts, rule.Host)
+			}
+		}
+
+		n, err := c4.NewInfrastructureNode(ctx, alias.Make(ns.ID(), "ing", obj.Metadata.Name), c4.InfrastructureNodeArgs{
+			Name:         obj.Metadata.Name,
+			Description:  strings.Join(hosts, ", "),
+			Technologies: []string{"Kubernetes Ingress"},
+			Properties:   labelProperties(obj.Metadata.Labels),
+		})
+		if err != nil {
+			return nil, err
+		}
+		ns.AddElement(ctx, n)
+
+		technology := "HTTP"
+		if len(obj.Spec.TLS) > 0 {
+			technology = "HTTPS"
+		}
+
+		related := map[string]bool{}
+		for _, rule := range obj.Spec.Rules {
+			for _, path := range rule.HTTP.Paths {
+				name := path.Backend.Service.Name
+				svc, ok := services[ns.ID()+"/"+name]
+				if !ok || related[name] {
+					continue
+				}
+				related[name] = true
+				res.Relations = append(res.Relations, c4.RelationArgs{
+					Src:          n,
+					Dst:          svc.node,
+					Description:  "Routes requests to",
+					Technologies: []string{technology},
+				})
+			}
+		}
+	}
+
+	return res, nil
+}
+
+func newWorkloadNode(ctx context.Context, obj object, args ImportArgs) (*c4.DeploymentNode, error) {
+	namespace := obj.Metadata.Namespace
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+
+	replicas := 1
+	if obj.Spec.Replicas != nil {
+		replicas = *obj.Spec.Replicas
+	}
+
+	containers := obj.Spec.Template.Spec.Containers
+	images := make([]string, 0, len(containers))
+	var properties []c4.Property
+	for _, c := range containers {
+		images = append(images, c.Image)
+
+		prefix := ""
+		if len(containers) > 1 {
+			prefix = c.Name + " "
+		}
+		if len(c.Resources.Requests) > 0 {
+			properties = append(properties, c4.Property{Name: prefix + "requests", Value: resources(c.Resources.Requests)})
+		}
+		if len(c.Resources.Limits) > 0 {
+			properties = append(properties, c4.Property{Name: prefix + "limits", Value: resources(c.Resources.Limits)})
+		}
+	}
+	properties = append(properties, labelProperties(obj.Metadata.Labels)...)
+
+	id := alias.Make(namespace, obj.Metadata.Name)
+	var elements []c4.Element
+	if el, ok := args.Elements[namespace+"/"+obj.Metadata.Name]; ok {
+		elements = append(elements, instance(el, id))
+	} else if el, ok := args.Elements[obj.Metadata.Name]; ok {
+		elements = append(elements, instance(el, id))
+	}
+
+	return c4.NewDeploymentNode(ctx, id, c4.DeploymentNodeArgs{
+		Name:        obj.Metadata.Name,
+		Type:        strings.Join(images, ", "),
+		Description: "Kubernetes " + obj.Kind,
+		Properties:  properties,
+		Elements:    elements,
+		Instances:   replicas,
+	})
+}
+
+// instance returns an instance of el to deploy within the workload node with
+// the given ID. The same element may be deployed by workloads in several
+// namespaces, so each needs its own identifier. Elements that can't be
+// instantiated are deployed as they are.
+func instance(el c4.Element, node string) c4.Element {
+	id := alias.Make(node, el.ID())
+	switch v := el.(type) {
+	case *c4.Container:
+		return v.Instance(id)
+	case *c4.Database:
+		return v.Instance(id)
+	case *c4.Queue:
+		return v.Instance(id)
+	}
+	return el
+}
+
+func labelProperties(labels map[string]string) []c4.Property {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	properties := make([]c4.Property, 0, len(keys))
+	for _, k := range keys {
+		properties = append(properties, c4.Property{Name: k, Value: labels[k]})
+	}
+	return properties
+}
+
+func resources(r map[string]string) string {
+	keys := make([]string, 0, len(r))
+	for k := range r {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	values := make([]string, 0, len(keys))
+	for _, k := range keys {
+		values = append(values, k+": "+r[k])
+	}
+	return strings.Join(values, ", ")
+}
+
+func portTechnologies(ports []servicePort) []string {
+	technologies := make([]string, 0, len(ports))
+	for _, p := range ports {
+		protocol := p.Protocol
+		if protocol == "" {
+			protocol = "TCP"
+		}
+		technologies = append(technologies, fmt.Sprintf("%s/%d", protocol, p.Port))
+	}
+	return technologies
+}
+
+func serviceType(t string) string {
+	if t == "" {
+		return "ClusterIP"
+	}
+	return t
+}
+
+func matches(selector, labels map[string]string) bool {
+	for k, v := range selector {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
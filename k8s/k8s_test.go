@@ -0,0 +1,145 @@
+package k8s
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/haleyrc/c4"
+)
+
+// tree describes the nodes in res, one element per line, by their path
+// through the enclosing nodes and their kind e.g. "prod/prod_api deploymentNode".
+func tree(res *Result) string {
+	var lines []string
+	var visit func(prefix string, el c4.Element)
+	visit = func(prefix string, el c4.Element) {
+		lines = append(lines, prefix+el.ID()+" "+string(c4.KindOf(el)))
+		if n, ok := el.(*c4.DeploymentNode); ok {
+			for _, child := range n.Elements() {
+				visit(prefix+el.ID()+"/", child)
+			}
+		}
+	}
+	for _, n := range res.Nodes {
+		visit("", n)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+	api := c4.MustNewContainer(ctx, "api", c4.ContainerArgs{Name: "API", Technologies: []string{"Go"}})
+	db := c4.MustNewDatabase(ctx, "db", c4.DatabaseArgs{Name: "Database", Technologies: []string{"PostgreSQL"}})
+
+	res, err := Import(ctx, ImportArgs{
+		Elements: map[string]c4.Element{
+			"api":     api,
+			"prod/db": db,
+			"db":      api,
+		},
+	}, filepath.Join("testdata", "manifests"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantTree := strings.Join([]string{
+		"prod deploymentNode",
+		"prod/prod_svc_api infrastructureNode",
+		"prod/prod_api deploymentNode",
+		"prod/prod_api/prod_api_api instance",
+		"prod/prod_worker deploymentNode",
+		"prod/prod_db deploymentNode",
+		"prod/prod_db/prod_db_db instance",
+		"prod/prod_svc_db infrastructureNode",
+		"prod/prod_svc_metrics infrastructureNode",
+		"prod/prod_ing_web infrastructureNode",
+		"staging deploymentNode",
+		"staging/staging_api deploymentNode",
+		"staging/staging_api/staging_api_api instance",
+		"staging/staging_svc_api infrastructureNode",
+		"staging/staging_ing_web infrastructureNode",
+	}, "\n")
+	if got := tree(res); got != wantTree {
+		t.Errorf("got nodes:\n%s\nwant:\n%s", got, wantTree)
+	}
+
+	var relations []string
+	for _, rel := range res.Relations {
+		relations = append(relations, rel.Src.ID()+" -> "+rel.Dst.ID()+" "+strings.Join(rel.Technologies, ", "))
+	}
+	wantRelations := strings.Join([]string{
+		"prod_svc_api -> prod_api TCP/80, UDP/9090",
+		"prod_svc_db -> prod_db TCP/5432",
+		"staging_svc_api -> staging_api TCP/80",
+		"prod_ing_web -> prod_svc_api HTTPS",
+		"staging_ing_web -> staging_svc_api HTTP",
+	}, "\n")
+	if got := strings.Join(relations, "\n"); got != wantRelations {
+		t.Errorf("got relations:\n%s\nwant:\n%s", got, wantRelations)
+	}
+
+	prod, staging := res.Nodes[0], res.Nodes[1]
+	prodAPI := prod.Elements()[1].(*c4.DeploymentNode)
+	if prodAPI.Instances() != 3 {
+		t.Errorf("got %d instances of prod_api, want 3", prodAPI.Instances())
+	}
+	if got, want := prodAPI.Type(), "example/api:1.2"; got != want {
+		t.Errorf("got type %q, want %q", got, want)
+	}
+	var props []string
+	for _, p := range prodAPI.Properties() {
+		props = append(props, p.Name+"="+p.Value)
+	}
+	if got, want := strings.Join(props, "; "), "requests=cpu: 100m, memory: 128Mi; team=payments"; got != want {
+		t.Errorf("got properties %q, want %q", got, want)
+	}
+
+	// The same container is deployed to both namespaces as distinct
+	// instances.
+	prodInstance := prodAPI.Elements()[0].(*c4.Instance)
+	stagingInstance := staging.Elements()[0].(*c4.DeploymentNode).Elements()[0].(*c4.Instance)
+	if prodInstance.ID() == stagingInstance.ID() {
+		t.Errorf("got the same instance ID %q in both namespaces", prodInstance.ID())
+	}
+	if prodInstance.Of() != api || stagingInstance.Of() != api {
+		t.Errorf("got instances of %s and %s, want %s", prodInstance.Of().ID(), stagingInstance.Of().ID(), api.ID())
+	}
+
+	// Namespace-qualified mappings take precedence over plain names.
+	dbInstance := prod.Elements()[3].(*c4.DeploymentNode).Elements()[0].(*c4.Instance)
+	if dbInstance.Of() != db {
+		t.Errorf("got an instance of %s in prod_db, want %s", dbInstance.Of().ID(), db.ID())
+	}
+}
+
+func TestDecodeDefaultNamespace(t *testing.T) {
+	ctx := context.Background()
+	src := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+        - name: web
+          image: nginx
+        - name: proxy
+          image: envoy
+`
+	res, err := Decode(ctx, strings.NewReader(src), ImportArgs{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "default deploymentNode\ndefault/default_web deploymentNode"
+	if got := tree(res); got != want {
+		t.Errorf("got nodes:\n%s\nwant:\n%s", got, want)
+	}
+	web := res.Nodes[0].Elements()[0].(*c4.DeploymentNode)
+	if got, want := web.Type(), "nginx, envoy"; got != want {
+		t.Errorf("got type %q, want %q", got, want)
+	}
+}
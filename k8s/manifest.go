@@ -0,0 +1,156 @@
+package k8s
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// object holds the subset of a Kubernetes object used to build deployment
+// nodes. Fields are shared between kinds where the manifests agree.
+type object struct {
+	Kind     string   `yaml:"kind"`
+	Metadata metadata `yaml:"metadata"`
+	Spec     spec     `yaml:"spec"`
+	Items    []object `yaml:"items"`
+}
+
+type metadata struct {
+	Name      string            `yaml:"name"`
+	Namespace string            `yaml:"namespace"`
+	Labels    map[string]string `yaml:"labels"`
+}
+
+type spec struct {
+	// Deployment and StatefulSet
+	Replicas *int        `yaml:"replicas"`
+	Template podTemplate `yaml:"template"`
+
+	// Service
+	Type     string        `yaml:"type"`
+	Selector yaml.Node     `yaml:"selector"`
+	Ports    []servicePort `yaml:"ports"`
+
+	// Ingress
+	TLS   []struct{} `yaml:"tls"`
+	Rules []struct {
+		Host string `yaml:"host"`
+		HTTP struct {
+			Paths []struct {
+				Path    string `yaml:"path"`
+				Backend struct {
+					Service struct {
+						Name string `yaml:"name"`
+					} `yaml:"service"`
+				} `yaml:"backend"`
+			} `yaml:"paths"`
+		} `yaml:"http"`
+	} `yaml:"rules"`
+}
+
+type podTemplate struct {
+	Metadata metadata `yaml:"metadata"`
+	Spec     struct {
+		Containers []podContainer `yaml:"containers"`
+	} `yaml:"spec"`
+}
+
+type podContainer struct {
+	Name      string `yaml:"name"`
+	Image     string `yaml:"image"`
+	Resources struct {
+		Requests map[string]string `yaml:"requests"`
+		Limits   map[string]string `yaml:"limits"`
+	} `yaml:"resources"`
+}
+
+type servicePort struct {
+	Protocol string `yaml:"protocol"`
+	Port     int    `yaml:"port"`
+}
+
+// selector returns the label selector of a Service. Services use a plain map
+// of labels rather than the matchLabels form used by workloads.
+func (s spec) selector() map[string]string {
+	var labels map[string]string
+	if s.Selector.Kind == yaml.MappingNode {
+		_ = s.Selector.Decode(&labels)
+	}
+	return labels
+}
+
+// readPaths decodes every manifest file in paths. Directories are searched
+// recursively for files with a .yaml, .yml or .json extension.
+func readPaths(paths []string) ([]object, error) {
+	var objs []object
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			if path != root && !isManifest(path) {
+				return nil
+			}
+
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			fileObjs, err := decode(f)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			objs = append(objs, fileObjs...)
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return objs, nil
+}
+
+func isManifest(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// decode reads every document in a multi-document YAML or JSON stream. List
+// objects are flattened into their items.
+func decode(r io.Reader) ([]object, error) {
+	var objs []object
+
+	dec := yaml.NewDecoder(r)
+	for {
+		var obj object
+		if err := dec.Decode(&obj); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		if strings.HasSuffix(obj.Kind, "List") {
+			objs = append(objs, obj.Items...)
+			continue
+		}
+		if obj.Kind != "" {
+			objs = append(objs, obj)
+		}
+	}
+
+	return objs, nil
+}
@@ -0,0 +1,163 @@
+package c4
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Markdown renders a textual report of the Diagram as Markdown to the provided
+// writer. The report embeds the diagram itself, followed by tables describing
+// its elements and relations, and the properties of any deployment nodes. This
+// makes the information in the diagram available to readers who can't see the
+// picture, and is suitable for including in design documents.
+//
+// By default, the diagram is embedded as a Mermaid code block, which is
+// rendered by many Markdown viewers. Use WithMarkdownImageServer to embed an
+// image from a PlantUML server instead.
+func (d *Diagram) Markdown(ctx context.Context, w io.Writer) error {
+	if err := d.Validate(ctx); err != nil {
+		return err
+	}
+
+	var buff bytes.Buffer
+
+	fmt.Fprintf(&buff, "# %s\n\n", markdownText(d.title))
+
+	if d.markdownImageServer != "" {
+		url, err := d.EncodedURL(ctx, d.markdownImageServer)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&buff, "![%s](%s)\n\n", markdownText(d.title), url)
+	} else {
+		fmt.Fprintln(&buff, "```mermaid")
+		if err := d.Mermaid(ctx, &buff); err != nil {
+			return err
+		}
+		fmt.Fprint(&buff, "```\n\n")
+	}
+
+	// Elements are listed along with the boundary or deployment node that
+	// they are displayed within, which also distinguishes instances of the
+	// same element. Identifiers are unique within a valid diagram, so they
+	// are used to find the parents of relation endpoints.
+	var (
+		els     []Element
+		parents = map[string]Element{}
+		nodes   []*DeploymentNode
+	)
+	var collect func(parent Element, group []Element)
+	collect = func(parent Element, group []Element) {
+		for _, el := range group {
+			els = append(els, el)
+			if parent != nil {
+				parents[el.ID()] = parent
+			}
+			if dn, ok := el.(*DeploymentNode); ok {
+				nodes = append(nodes, dn)
+			}
+			collect(el, children(el))
+		}
+	}
+	collect(nil, d.elements)
+
+	fmt.Fprint(&buff, "## Elements\n\n")
+	fmt.Fprintln(&buff, "| Element | Type | Technologies | External | Within | Description |")
+	fmt.Fprintln(&buff, "| --- | --- | --- | --- | --- | --- |")
+	for _, el := range els {
+		md := summarize(el)
+		external := "No"
+		if md.external {
+			external = "Yes"
+		}
+		within := ""
+		if parent, ok := parents[el.ID()]; ok {
+			within = summarize(parent).name
+		}
+		fmt.Fprintf(&buff, "| %s | %s | %s | %s | %s | %s |\n",
+			markdownText(md.name),
+			md.kind,
+			markdownText(strings.Join(md.technologies, ", ")),
+			external,
+			markdownText(within),
+			markdownText(md.description),
+		)
+	}
+
+	relations := d.renderedRelations()
+	if len(relations) > 0 {
+		fmt.Fprint(&buff, "\n## Relations\n\n")
+		fmt.Fprintln(&buff, "| Source | Description | Destination | Technologies |")
+		fmt.Fprintln(&buff, "| --- | --- | --- | --- |")
+		for _, rel := range relations {
+			fmt.Fprintf(&buff, "| %s | %s | %s | %s |\n",
+				markdownText(markdownEndpoint(rel.src, parents)),
+				markdownText(rel.description),
+				markdownText(markdownEndpoint(rel.dst, parents)),
+				markdownText(strings.Join(rel.technologies, ", ")),
+			)
+		}
+	}
+
+	if len(nodes) > 0 {
+		fmt.Fprint(&buff, "\n## Deployment Nodes\n")
+		for _, dn := range nodes {
+			fmt.Fprintf(&buff, "\n### %s\n\n", markdownText(dn.name))
+			if dn.nodeType != "" {
+				fmt.Fprintf(&buff, "- Type: %s\n", markdownText(dn.nodeType))
+			}
+			if dn.instances > 1 {
+				fmt.Fprintf(&buff, "- Instances: %d\n", dn.instances)
+			}
+			if dn.description != "" {
+				fmt.Fprintf(&buff, "- Description: %s\n", markdownText(dn.description))
+			}
+			if len(dn.properties) > 0 {
+				fmt.Fprintln(&buff)
+				fmt.Fprintln(&buff, "| Property | Value |")
+				fmt.Fprintln(&buff, "| --- | --- |")
+				for _, p := range dn.properties {
+					fmt.Fprintf(&buff, "| %s | %s |\n", markdownText(p.Name), markdownText(p.Value))
+				}
+			}
+		}
+	}
+
+	if _, err := io.Copy(w, &buff); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// markdownEndpoint returns the name of a relation's element. Instances share
+// the name of their element, so the deployment node they are within is added
+// to tell them apart.
+func markdownEndpoint(el Element, parents map[string]Element) string {
+	name := summarize(el).name
+	if _, ok := el.(*Instance); ok {
+		if parent, ok := parents[el.ID()]; ok {
+			name += " (" + summarize(parent).name + ")"
+		}
+	}
+	return name
+}
+
+var markdownReplacer = strings.NewReplacer(
+	`\`, `\\`,
+	"|", `\|`,
+	"*", `\*`,
+	"_", `\_`,
+	"`", "\\`",
+	"\r\n", " ",
+	"\n", " ",
+)
+
+// markdownText escapes s for use within a Markdown table or heading, so that
+// names such as "bigbank-api***" aren't displayed with emphasis.
+func markdownText(s string) string {
+	return markdownReplacer.Replace(s)
+}
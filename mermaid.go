@@ -0,0 +1,184 @@
+package c4
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Mermaid renders the Diagram as a Mermaid C4 diagram
+// (https://mermaid.js.org/syntax/c4.html) to the provided writer. Mermaid
+// supports a subset of C4-PlantUML, so options that only affect PlantUML
+// output, such as themes, sprites, properties and includes, are ignored.
+func (d *Diagram) Mermaid(ctx context.Context, w io.Writer) error {
+	if err := d.Validate(ctx); err != nil {
+		return err
+	}
+
+	var buff bytes.Buffer
+
+	fmt.Fprintln(&buff, mermaidDiagramType(d.elements))
+	fmt.Fprintf(&buff, "title %s\n", d.title)
+	fmt.Fprintln(&buff)
+
+	for _, el := range d.elements {
+		if err := mermaid(ctx, &buff, el); err != nil {
+			return err
+		}
+	}
+
+	for _, rel := range d.renderedRelations() {
+		if err := mermaid(ctx, &buff, rel); err != nil {
+			return err
+		}
+	}
+
+	if _, err := io.Copy(w, &buff); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// mermaidDiagramType returns the Mermaid diagram type for the most detailed
+// level of element in els.
+func mermaidDiagramType(els []Element) string {
+	typ := "C4Context"
+	for _, lib := range requiredLibraries(els) {
+		switch lib {
+		case C4Deployment:
+			return "C4Deployment"
+		case C4Component:
+			typ = "C4Component"
+		case C4Container:
+			if typ == "C4Context" {
+				typ = "C4Container"
+			}
+		}
+	}
+	return typ
+}
+
+func mermaid(ctx context.Context, w io.Writer, el interface{}) error {
+	switch v := el.(type) {
+	case *Component:
+		prefix := "Component" + string(v.shape)
+		if v.external {
+			prefix += "_Ext"
+		}
+		technologies := strings.Join(v.technologies, ", ")
+		fmt.Fprintf(w, `%s(%s, "%s", "%s", "%s")`, prefix, v.ID(), mermaidString(v.name), mermaidString(technologies), mermaidString(v.description))
+		fmt.Fprintln(w)
+	case *Container:
+		prefix := "Container"
+		if v.external {
+			prefix += "_Ext"
+		}
+		technologies := strings.Join(v.technologies, ", ")
+		fmt.Fprintf(w, `%s(%s, "%s", "%s", "%s")`, prefix, v.ID(), mermaidString(v.name), mermaidString(technologies), mermaidString(v.description))
+		fmt.Fprintln(w)
+	case *containerBoundary:
+		return writeMermaidBoundary(ctx, w, "Container_Boundary", v.ID(), v.name, v.elements)
+	case *databaseBoundary:
+		return writeMermaidBoundary(ctx, w, "Container_Boundary", v.ID(), v.name, v.elements)
+	case *EnterpriseBoundary:
+		return writeMermaidBoundary(ctx, w, "Enterprise_Boundary", v.ID(), v.name, v.elements)
+	case *Database:
+		prefix := "ContainerDb"
+		if v.external {
+			prefix += "_Ext"
+		}
+		technologies := strings.Join(v.technologies, ", ")
+		fmt.Fprintf(w, `%s(%s, "%s", "%s", "%s")`, prefix, v.ID(), mermaidString(v.name), mermaidString(technologies), mermaidString(v.description))
+		fmt.Fprintln(w)
+	case *DeploymentNode:
+		prefix := "Deployment_Node"
+		if v.alignment != AlignmentCenter {
+			prefix = "Node" + string(v.alignment)
+		}
+		label := v.name
+		if v.instances > 1 {
+			label = fmt.Sprintf("%s x%d", v.name, v.instances)
+		}
+		fmt.Fprintf(w, `%s(%s, "%s", "%s", "%s") {`, prefix, v.ID(), mermaidString(label), mermaidString(v.nodeType), mermaidString(v.description))
+		fmt.Fprintln(w)
+		if err := writeMermaidChildren(ctx, w, v.elements); err != nil {
+			return err
+		}
+		fmt.Fprintln(w, "}")
+	case *InfrastructureNode:
+		technologies := strings.Join(v.technologies, ", ")
+		fmt.Fprintf(w, `Node(%s, "%s", "%s", "%s") {`, v.ID(), mermaidString(v.name), mermaidString(technologies), mermaidString(v.description))
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "}")
+	case *Instance:
+		return mermaid(ctx, w, v.element())
+	case *Person:
+		prefix := "Person"
+		if v.external {
+			prefix += "_Ext"
+		}
+		fmt.Fprintf(w, `%s(%s, "%s", "%s")`, prefix, v.ID(), mermaidString(v.name), mermaidString(v.description))
+		fmt.Fprintln(w)
+	case *Queue:
+		prefix := "ContainerQueue"
+		if v.external {
+			prefix += "_Ext"
+		}
+		technologies := strings.Join(v.technologies, ", ")
+		fmt.Fprintf(w, `%s(%s, "%s", "%s", "%s")`, prefix, v.ID(), mermaidString(v.name), mermaidString(technologies), mermaidString(v.description))
+		fmt.Fprintln(w)
+	case *relation:
+		prefix := "Rel"
+		if v.direction != "" {
+			prefix = fmt.Sprintf("Rel_%s", v.direction)
+		}
+		fmt.Fprintf(w, `%s(%s, %s, "%s", "%s")`, prefix, v.src.ID(), v.dst.ID(), mermaidString(v.description), mermaidString(strings.Join(v.technologies, ",")))
+		fmt.Fprintln(w)
+	case *systemBoundary:
+		return writeMermaidBoundary(ctx, w, "System_Boundary", v.ID(), v.name, v.elements)
+	case *System:
+		prefix := "System" + string(v.shape)
+		if v.external {
+			prefix += "_Ext"
+		}
+		fmt.Fprintf(w, `%s(%s, "%s", "%s")`, prefix, v.ID(), mermaidString(v.name), mermaidString(v.description))
+		fmt.Fprintln(w)
+	default:
+		return fmt.Errorf("cannot create mermaid: invalid item type: %T", el)
+	}
+	return nil
+}
+
+func writeMermaidBoundary(ctx context.Context, w io.Writer, macro, id, name string, els []Element) error {
+	fmt.Fprintf(w, `%s(%s, "%s") {`, macro, id, mermaidString(name))
+	fmt.Fprintln(w)
+	if err := writeMermaidChildren(ctx, w, els); err != nil {
+		return err
+	}
+	fmt.Fprintln(w, "}")
+	return nil
+}
+
+func writeMermaidChildren(ctx context.Context, w io.Writer, els []Element) error {
+	for _, el := range els {
+		var buff bytes.Buffer
+		if err := mermaid(ctx, &buff, el); err != nil {
+			return err
+		}
+		s := bufio.NewScanner(&buff)
+		for s.Scan() {
+			fmt.Fprintf(w, "\t%s\n", s.Text())
+		}
+	}
+	return nil
+}
+
+// mermaidString escapes double quotes, which Mermaid doesn't allow within
+// quoted strings, using its entity syntax.
+func mermaidString(s string) string {
+	return strings.ReplaceAll(s, `"`, "#quot;")
+}
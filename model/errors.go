@@ -0,0 +1,73 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Error describes a problem at a specific location in a model file. Errors are
+// designed to be serialized as JSON for consumption by other tools.
+type Error struct {
+	// The name of the model file, if known.
+	File string `json:"file,omitempty"`
+
+	// The line on which the problem occurs, if known.
+	Line int `json:"line,omitempty"`
+
+	// The identifier of the element, or the key of the view, with the
+	// problem, if any.
+	ID string `json:"id,omitempty"`
+
+	// A description of the problem.
+	Message string `json:"message"`
+}
+
+// Error satisfies the error interface. The location of the problem is
+// formatted as "file:line" as is conventional for compilers and linters.
+func (e *Error) Error() string {
+	var b strings.Builder
+	if e.File != "" {
+		b.WriteString(e.File)
+		b.WriteString(":")
+	}
+	if e.Line > 0 {
+		fmt.Fprintf(&b, "%d:", e.Line)
+	}
+	if b.Len() > 0 {
+		b.WriteString(" ")
+	}
+	if e.ID != "" {
+		b.WriteString(e.ID)
+		b.WriteString(": ")
+	}
+	b.WriteString(e.Message)
+	return b.String()
+}
+
+// Errors is a list of problems with a model. Load returns an Errors value
+// describing every problem it finds rather than stopping at the first.
+type Errors []*Error
+
+// Error satisfies the error interface, reporting one problem per line.
+func (errs Errors) Error() string {
+	msgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+var yamlLineRE = regexp.MustCompile(`^yaml: (?:unmarshal errors:\s*)?line (\d+): (.*)$`)
+
+// decodeError converts an error from the YAML decoder into an Error, extracting
+// the line number where possible.
+func decodeError(err error) *Error {
+	msg := strings.TrimSpace(err.Error())
+	if m := yamlLineRE.FindStringSubmatch(msg); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		return &Error{Line: line, Message: m[2]}
+	}
+	return &Error{Message: strings.TrimPrefix(msg, "yaml: ")}
+}
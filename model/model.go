@@ -0,0 +1,835 @@
+// Package model loads c4 architecture models from YAML or JSON files.
+//
+// A model declares every element and relation once, along with a set of views
+// that select the elements to display in each diagram. This allows diagrams to
+// be produced without writing a Go program for each one, for example with the
+// c4 command in cmd/c4.
+//
+//	title: Big Bank plc
+//	elements:
+//	  - id: customer
+//	    kind: person
+//	    name: Personal Banking Customer
+//	  - id: internetBanking
+//	    kind: system
+//	    name: Internet Banking System
+//	    elements:
+//	      - id: api
+//	        kind: container
+//	        name: API Application
+//	        technologies: [Java, Spring MVC]
+//	        properties:
+//	          Owner: Digital Team
+//	relations:
+//	  - src: customer
+//	    dst: api
+//	    description: Uses
+//	    technologies: [JSON/HTTPS]
+//	views:
+//	  - key: context
+//	    title: System Context
+//	    include: [customer, internetBanking]
+//	  - key: containers
+//	    title: Containers
+//	    include: [customer, internetBanking, api]
+//	    exclude: []
+//
+// Element kinds use the values of c4.Kind, such as person, system, container,
+// component, database, queue, deploymentNode, infrastructureNode,
+// enterpriseBoundary and instance. Elements may be nested within the elements
+// field of their parent: containers, databases and queues within systems;
+// components within containers and databases; and anything deployable within
+// deployment nodes. Instances refer to the container, database or queue they
+// are an instance of using the of field.
+//
+// A view includes the listed elements, or every element if include is empty
+// or contains "*". Including a deployment node or enterprise boundary also
+// includes its children, while systems, containers and databases are shown as
+// boundaries only when some of their children are included too. Excluding an
+// element also excludes its children. Relations are shown when both of their
+// elements are included, unless either element is shown as a boundary.
+//
+// A view may focus on one of its elements, showing only the elements within
+// depth relations of it (1 by default) along with the boundaries containing
+// them. Set follow to inbound or outbound to only follow relations in that
+// direction.
+//
+//	views:
+//	  - key: api
+//	    title: API Application
+//	    focus: api
+//	    depth: 2
+//	    follow: outbound
+//
+// Views may also be filtered using the tags given to elements: includeTags
+// keeps only the elements with one of the listed tags, while excludeTags
+// removes them. Setting excludeExternal removes external elements, and types
+// keeps only the elements of the listed kinds. Systems, containers, databases,
+// deployment nodes and enterprise boundaries left empty by the filters are
+// removed, along with any relations to the elements that were filtered out.
+//
+//	elements:
+//	  - id: payments
+//	    kind: system
+//	    name: Payments
+//	    tags: [pci]
+//	views:
+//	  - key: pci
+//	    title: PCI Scope
+//	    includeTags: [pci]
+//	    excludeExternal: true
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/haleyrc/c4"
+)
+
+// DefaultViewKey is the key of the view generated for models that don't
+// declare any views. The view includes every element.
+const DefaultViewKey = "default"
+
+// Model is a loaded architecture model.
+type Model struct {
+	title     string
+	nodes     []*node
+	byID      map[string]*node
+	relations []*relationSpec
+	views     []*viewSpec
+}
+
+// node is an element of the model along with its position in the hierarchy.
+type node struct {
+	spec     *elementSpec
+	parent   *node
+	children []*node
+
+	// The element shared by every view. Elements that group other elements,
+	// such as deployment nodes, are recreated for each view so that they
+	// only contain the elements included in that view.
+	element c4.Element
+}
+
+// View describes a diagram that can be produced from the model.
+type View struct {
+	// The unique key of the view e.g. "containers".
+	Key string
+
+	// The title of the resultant diagram.
+	Title string
+}
+
+// LoadFile is the same as Load, but reads the named model file. The file name
+// is recorded in any validation errors.
+func LoadFile(ctx context.Context, name string) (*Model, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("model: load: %w", err)
+	}
+	defer f.Close()
+
+	m, err := Load(ctx, f)
+	if err != nil {
+		var errs Errors
+		if errors.As(err, &errs) {
+			for _, e := range errs {
+				e.File = name
+			}
+		}
+		return nil, err
+	}
+	return m, nil
+}
+
+// Load reads a model in either YAML or JSON form from r. If the model is
+// invalid, the returned error is an Errors value describing each problem.
+func Load(ctx context.Context, r io.Reader) (*Model, error) {
+	var f file
+	if err := yaml.NewDecoder(r).Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return nil, Errors{decodeError(err)}
+	}
+
+	m := &Model{
+		title:     f.Title,
+		byID:      map[string]*node{},
+		relations: f.Relations,
+		views:     f.Views,
+	}
+	if m.title == "" {
+		m.title = "Model"
+	}
+
+	var errs Errors
+	m.nodes = m.addNodes(nil, f.Elements, &errs)
+	m.validateRelations(&errs)
+	m.validateViews(&errs)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	if err := m.build(ctx); err != nil {
+		return nil, Errors{{Message: err.Error()}}
+	}
+
+	return m, nil
+}
+
+var idRE = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// addNodes records the nodes for specs and their children, reporting any
+// problems with the element declarations to errs.
+func (m *Model) addNodes(parent *node, specs []*elementSpec, errs *Errors) []*node {
+	var nodes []*node
+	for _, spec := range specs {
+		fail := func(format string, args ...interface{}) {
+			*errs = append(*errs, &Error{Line: spec.line, ID: spec.ID, Message: fmt.Sprintf(format, args...)})
+		}
+
+		switch {
+		case spec.ID == "":
+			fail("an id is required")
+		case !idRE.MatchString(spec.ID):
+			fail("invalid id: ids may only contain letters, digits and underscores and must not start with a digit")
+		case m.byID[spec.ID] != nil:
+			fail("duplicate id: first declared on line %d", m.byID[spec.ID].spec.line)
+		}
+
+		var parentKind c4.Kind
+		if parent != nil {
+			parentKind = parent.spec.Kind
+		}
+		if !validKind(spec.Kind) {
+			fail("invalid kind %q", spec.Kind)
+		} else if !allowed(parentKind, spec.Kind) {
+			if parent == nil {
+				fail("%s must be nested within a deployment node", spec.Kind)
+			} else {
+				fail("%s cannot be nested within %s %s", spec.Kind, parentKind, parent.spec.ID)
+			}
+		}
+
+		if spec.Kind != c4.KindInstance && spec.Name == "" {
+			fail("a name is required")
+		}
+		if spec.Kind == c4.KindInstance && spec.Of == "" {
+			fail("instances require the id of the element they are an instance of")
+		}
+		if _, ok := shapes[spec.Shape]; !ok {
+			fail("invalid shape %q", spec.Shape)
+		} else if spec.Shape != "" && spec.Kind != c4.KindSystem && spec.Kind != c4.KindComponent {
+			fail("shapes are only supported for systems and components")
+		}
+		if _, ok := alignments[spec.Alignment]; !ok {
+			fail("invalid alignment %q", spec.Alignment)
+		}
+
+		n := &node{spec: spec, parent: parent}
+		if spec.ID != "" && m.byID[spec.ID] == nil {
+			m.byID[spec.ID] = n
+		}
+		n.children = m.addNodes(n, spec.Elements, errs)
+		nodes = append(nodes, n)
+	}
+
+	// Instances are checked once every element has been declared since they
+	// may refer to elements declared later in the file.
+	if parent == nil {
+		m.walk(nodes, func(n *node) {
+			if n.spec.Kind != c4.KindInstance || n.spec.Of == "" {
+				return
+			}
+			of, ok := m.byID[n.spec.Of]
+			switch {
+			case !ok:
+				*errs = append(*errs, &Error{Line: n.spec.line, ID: n.spec.ID, Message: fmt.Sprintf("unknown element %q", n.spec.Of)})
+			case of.spec.Kind != c4.KindContainer && of.spec.Kind != c4.KindDatabase && of.spec.Kind != c4.KindQueue:
+				*errs = append(*errs, &Error{Line: n.spec.line, ID: n.spec.ID, Message: fmt.Sprintf("instances must be of a container, database or queue, not %s", of.spec.Kind)})
+			}
+		})
+	}
+
+	return nodes
+}
+
+func (m *Model) validateRelations(errs *Errors) {
+	for _, rel := range m.relations {
+		for _, id := range []string{rel.Src, rel.Dst} {
+			switch {
+			case id == "":
+				*errs = append(*errs, &Error{Line: rel.line, Message: "relations require a src and dst"})
+			case m.byID[id] == nil:
+				*errs = append(*errs, &Error{Line: rel.line, ID: id, Message: "unknown element"})
+			}
+		}
+		if _, ok := directions[rel.Direction]; !ok {
+			*errs = append(*errs, &Error{Line: rel.line, Message: fmt.Sprintf("invalid direction %q", rel.Direction)})
+		}
+	}
+}
+
+func (m *Model) validateViews(errs *Errors) {
+	seen := map[string]*viewSpec{}
+	for _, v := range m.views {
+		fail := func(format string, args ...interface{}) {
+			*errs = append(*errs, &Error{Line: v.line, ID: v.Key, Message: fmt.Sprintf(format, args...)})
+		}
+
+		switch {
+		case v.Key == "":
+			fail("views require a key")
+		case seen[v.Key] != nil:
+			fail("duplicate view key: first declared on line %d", seen[v.Key].line)
+		default:
+			seen[v.Key] = v
+		}
+
+		for _, ids := range [][]string{v.Include, v.Exclude} {
+			for _, id := range ids {
+				if id != "*" && m.byID[id] == nil {
+					fail("unknown element %q", id)
+				}
+			}
+		}
+		if _, ok := layouts[v.Layout]; !ok {
+			fail("invalid layout %q", v.Layout)
+		}
+		if v.Focus != "" && m.byID[v.Focus] == nil {
+			fail("unknown focus element %q", v.Focus)
+		}
+		if v.Depth != nil && *v.Depth < 0 {
+			fail("invalid depth %d: depth must not be negative", *v.Depth)
+		}
+		if _, ok := follows[v.Follow]; !ok {
+			fail("invalid follow %q", v.Follow)
+		}
+		for _, kind := range v.Types {
+			if !validKind(kind) {
+				fail("invalid type %q", kind)
+			}
+		}
+		for _, lib := range v.Sprites {
+			if _, ok := sprites[lib]; !ok {
+				fail("invalid sprite library %q", lib)
+			}
+		}
+	}
+}
+
+// build constructs the elements shared by every view.
+func (m *Model) build(ctx context.Context) error {
+	var err error
+	m.walk(m.nodes, func(n *node) {
+		if err != nil || n.spec.Kind == c4.KindInstance {
+			return
+		}
+		n.element, err = newElement(ctx, n.spec)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Instances refer to other elements, so they are created once the shared
+	// elements exist. The deployment nodes and enterprise boundaries created
+	// here contain every child and are only used outside of views.
+	m.walk(m.nodes, func(n *node) {
+		if n.spec.Kind == c4.KindInstance {
+			n.element = instance(m.byID[n.spec.Of].element, n.spec.ID)
+		}
+	})
+	m.walk(m.nodes, func(n *node) {
+		if b, ok := n.element.(c4.Boundary); ok {
+			for _, child := range n.children {
+				b.AddElement(ctx, child.element)
+			}
+		}
+	})
+
+	return nil
+}
+
+// walk calls fn for each node in nodes and, recursively, for each of their
+// children.
+func (m *Model) walk(nodes []*node, fn func(*node)) {
+	for _, n := range nodes {
+		fn(n)
+		m.walk(n.children, fn)
+	}
+}
+
+// Title returns the title of the model.
+func (m *Model) Title() string { return m.title }
+
+// Lookup returns the element with the given identifier.
+func (m *Model) Lookup(id string) (c4.Element, bool) {
+	n, ok := m.byID[id]
+	if !ok {
+		return nil, false
+	}
+	return n.element, true
+}
+
+// Relations returns the arguments of every relation in the model.
+func (m *Model) Relations() []c4.RelationArgs {
+	args := make([]c4.RelationArgs, 0, len(m.relations))
+	for _, rel := range m.relations {
+		args = append(args, c4.RelationArgs{
+			Src:          m.byID[rel.Src].element,
+			Dst:          m.byID[rel.Dst].element,
+			Description:  rel.Description,
+			Technologies: rel.Technologies,
+			Properties:   rel.Properties,
+		})
+	}
+	return args
+}
+
+// Views returns the views declared by the model. Models without any views
+// have a single view, with the key DefaultViewKey, that includes every
+// element.
+func (m *Model) Views() []View {
+	specs := m.viewSpecs()
+	views := make([]View, 0, len(specs))
+	for _, v := range specs {
+		views = append(views, View{Key: v.Key, Title: v.title(m)})
+	}
+	return views
+}
+
+func (m *Model) viewSpecs() []*viewSpec {
+	if len(m.views) == 0 {
+		return []*viewSpec{{Key: DefaultViewKey, InstanceRelations: true}}
+	}
+	return m.views
+}
+
+func (v *viewSpec) title(m *Model) string {
+	if v.Title != "" {
+		return v.Title
+	}
+	if v.Key == DefaultViewKey {
+		return m.title
+	}
+	return v.Key
+}
+
+// Diagram constructs the diagram for the view with the given key. Any options
+// are applied after those declared by the view.
+func (m *Model) Diagram(ctx context.Context, key string, opts ...c4.DiagramOption) (*c4.Diagram, error) {
+	for _, v := range m.viewSpecs() {
+		if v.Key == key {
+			return m.diagram(ctx, v, opts...)
+		}
+	}
+	return nil, fmt.Errorf("model: unknown view %q", key)
+}
+
+// All constructs a diagram containing every element and relation in the
+// model. Every sprite library is enabled, so that each element can be shown
+// with its sprite.
+func (m *Model) All(ctx context.Context, opts ...c4.DiagramOption) (*c4.Diagram, error) {
+	return m.diagram(ctx, &viewSpec{Title: m.title, InstanceRelations: true}, append([]c4.DiagramOption{m.allSprites()}, opts...)...)
+}
+
+// allSprites enables the sprite libraries used by any element of the model.
+func (m *Model) allSprites() c4.DiagramOption {
+	var libs []c4.SpriteLibrary
+	seen := map[c4.SpriteLibrary]bool{}
+	m.walk(m.nodes, func(n *node) {
+		prefix, _, _ := strings.Cut(n.spec.Sprite, "/")
+		if lib, ok := sprites[prefix]; ok && !seen[lib] {
+			seen[lib] = true
+			libs = append(libs, lib)
+		}
+	})
+	return c4.WithSprites(libs...)
+}
+
+// Diff compares two versions of a model, reporting the elements and relations
+// that were added, removed or changed. Every element and relation is compared,
+// regardless of the views they appear in, and relations are reported by the
+// elements they were declared between. Use c4.Diff with diagrams from each
+// model to compare a single view.
+func Diff(ctx context.Context, old, new *Model) (*c4.Changes, error) {
+	od, err := old.declared(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nd, err := new.declared(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c4.Diff(ctx, od, nd)
+}
+
+// declared constructs a diagram containing every element and each relation as
+// it was declared. Unlike All, relations aren't copied onto instances or
+// hidden when their elements are shown as boundaries.
+func (m *Model) declared(ctx context.Context) (*c4.Diagram, error) {
+	d, err := c4.NewDiagram(ctx, m.title, m.allSprites())
+	if err != nil {
+		return nil, err
+	}
+
+	placed := map[string]c4.Element{}
+	if err := m.place(ctx, d, m.nodes, m.included(&viewSpec{}), placed); err != nil {
+		return nil, err
+	}
+
+	for _, rel := range m.relations {
+		err := d.NewRelation(ctx, c4.RelationArgs{
+			Src:          m.viewElement(placed, rel.Src),
+			Dst:          m.viewElement(placed, rel.Dst),
+			Description:  rel.Description,
+			Technologies: rel.Technologies,
+			Properties:   rel.Properties,
+		}, c4.WithDirection(directions[rel.Direction]))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return d, nil
+}
+
+func (m *Model) diagram(ctx context.Context, v *viewSpec, opts ...c4.DiagramOption) (*c4.Diagram, error) {
+	viewOpts := []c4.DiagramOption{c4.WithLayout(layouts[v.Layout])}
+	if v.Legend {
+		viewOpts = append(viewOpts, c4.WithLegend())
+	}
+	if v.Sketch {
+		viewOpts = append(viewOpts, c4.AsSketch())
+	}
+	if v.HideElementTypes {
+		viewOpts = append(viewOpts, c4.HideElementTypes())
+	}
+	if v.InstanceRelations {
+		viewOpts = append(viewOpts, c4.WithInstanceRelations())
+	}
+	for _, lib := range v.Sprites {
+		viewOpts = append(viewOpts, c4.WithSprites(sprites[lib]))
+	}
+
+	d, err := c4.NewDiagram(ctx, v.title(m), append(viewOpts, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	included := m.included(v)
+	placed := map[string]c4.Element{}
+	if err := m.place(ctx, d, m.nodes, included, placed); err != nil {
+		return nil, err
+	}
+
+	// When relations are copied onto instances, a relation is also shown if
+	// its elements are only present as instances. Relations of elements that
+	// are displayed as boundaries are summarized by the relations of their
+	// children, so they are hidden.
+	visible := map[string]bool{}
+	for id, el := range placed {
+		if m.bounded(id, el) {
+			continue
+		}
+		visible[id] = true
+		if inst, ok := el.(*c4.Instance); ok && v.InstanceRelations {
+			visible[inst.Of().ID()] = true
+		}
+	}
+
+	for _, rel := range m.relations {
+		if !visible[rel.Src] || !visible[rel.Dst] {
+			continue
+		}
+		err := d.NewRelation(ctx, c4.RelationArgs{
+			Src:          m.viewElement(placed, rel.Src),
+			Dst:          m.viewElement(placed, rel.Dst),
+			Description:  rel.Description,
+			Technologies: rel.Technologies,
+			Properties:   rel.Properties,
+		}, c4.WithDirection(directions[rel.Direction]))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var filters []c4.FilterOption
+	if len(v.IncludeTags) > 0 {
+		filters = append(filters, c4.IncludeTags(v.IncludeTags...))
+	}
+	if len(v.ExcludeTags) > 0 {
+		filters = append(filters, c4.ExcludeTags(v.ExcludeTags...))
+	}
+	if v.ExcludeExternal {
+		filters = append(filters, c4.ExcludeExternal())
+	}
+	if len(v.Types) > 0 {
+		filters = append(filters, c4.OnlyTypes(v.Types...))
+	}
+	if len(filters) > 0 {
+		var err error
+		if d, err = d.Filter(ctx, filters...); err != nil {
+			return nil, err
+		}
+	}
+
+	if v.Focus != "" {
+		el, ok := placed[v.Focus]
+		if !ok {
+			return nil, fmt.Errorf("model: focus element %q is not included in view %s", v.Focus, v.Key)
+		}
+		depth := 1
+		if v.Depth != nil {
+			depth = *v.Depth
+		}
+		return d.Focus(ctx, el, depth, follows[v.Follow]...)
+	}
+
+	return d, nil
+}
+
+// bounded reports whether the element with the given identifier has been
+// placed as a boundary in place of the element itself.
+func (m *Model) bounded(id string, el c4.Element) bool {
+	if _, ok := el.(c4.Boundary); !ok {
+		return false
+	}
+	switch m.byID[id].spec.Kind {
+	case c4.KindSystem, c4.KindContainer, c4.KindDatabase:
+		return true
+	}
+	return false
+}
+
+// viewElement returns the element used for id within a view. Boundaries are
+// displayed using the identifier of their element, so relations refer to the
+// shared element, except for deployment nodes which are validated against
+// the nodes in the diagram.
+func (m *Model) viewElement(placed map[string]c4.Element, id string) c4.Element {
+	if el, ok := placed[id]; ok {
+		if _, ok := el.(*c4.DeploymentNode); ok {
+			return el
+		}
+	}
+	return m.byID[id].element
+}
+
+// included returns the nodes included in a view.
+func (m *Model) included(v *viewSpec) map[*node]bool {
+	in := map[*node]bool{}
+	all := len(v.Include) == 0
+	for _, id := range v.Include {
+		if id == "*" {
+			all = true
+		}
+	}
+
+	if all {
+		m.walk(m.nodes, func(n *node) { in[n] = true })
+	} else {
+		for _, id := range v.Include {
+			n := m.byID[id]
+			in[n] = true
+			if n.spec.Kind == c4.KindDeploymentNode || n.spec.Kind == c4.KindEnterpriseBoundary {
+				m.walk(n.children, func(child *node) { in[child] = true })
+			}
+		}
+	}
+
+	for _, id := range v.Exclude {
+		if id == "*" {
+			return map[*node]bool{}
+		}
+		n := m.byID[id]
+		delete(in, n)
+		m.walk(n.children, func(child *node) { delete(in, child) })
+	}
+
+	return in
+}
+
+type elementAdder interface {
+	AddElement(ctx context.Context, el c4.Element)
+}
+
+// place adds the included nodes to parent. Nodes that aren't included are
+// skipped, but any of their included children are added in their place.
+func (m *Model) place(ctx context.Context, parent elementAdder, nodes []*node, in map[*node]bool, placed map[string]c4.Element) error {
+	for _, n := range nodes {
+		if !in[n] {
+			if err := m.place(ctx, parent, n.children, in, placed); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var el c4.Element
+		switch n.spec.Kind {
+		case c4.KindDeploymentNode, c4.KindEnterpriseBoundary:
+			group, err := newElement(ctx, n.spec)
+			if err != nil {
+				return err
+			}
+			if err := m.place(ctx, group.(c4.Boundary), n.children, in, placed); err != nil {
+				return err
+			}
+			el = group
+		case c4.KindInstance:
+			el = instance(m.byID[n.spec.Of].element, n.spec.ID)
+		default:
+			el = n.element
+			bounded, ok := el.(interface{ Boundary() c4.Boundary })
+			if ok && m.anyIncluded(n.children, in) {
+				b := bounded.Boundary()
+				if err := m.place(ctx, b, n.children, in, placed); err != nil {
+					return err
+				}
+				el = b
+			}
+		}
+
+		parent.AddElement(ctx, el)
+		placed[n.spec.ID] = el
+	}
+	return nil
+}
+
+func (m *Model) anyIncluded(nodes []*node, in map[*node]bool) bool {
+	found := false
+	m.walk(nodes, func(n *node) {
+		if in[n] {
+			found = true
+		}
+	})
+	return found
+}
+
+func validKind(kind c4.Kind) bool {
+	for _, kinds := range children {
+		for _, k := range kinds {
+			if k == kind {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func allowed(parent, kind c4.Kind) bool {
+	for _, k := range children[parent] {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func instance(of c4.Element, id string) c4.Element {
+	switch v := of.(type) {
+	case *c4.Container:
+		return v.Instance(id)
+	case *c4.Database:
+		return v.Instance(id)
+	case *c4.Queue:
+		return v.Instance(id)
+	}
+	return nil
+}
+
+func newElement(ctx context.Context, spec *elementSpec) (c4.Element, error) {
+	switch spec.Kind {
+	case c4.KindPerson:
+		return c4.NewPerson(ctx, spec.ID, c4.PersonArgs{
+			Name:        spec.Name,
+			Description: spec.Description,
+			External:    spec.External,
+			Sprite:      spec.Sprite,
+			Properties:  spec.Properties,
+			Tags:        spec.Tags,
+		})
+	case c4.KindSystem:
+		return c4.NewSystem(ctx, spec.ID, c4.SystemArgs{
+			Name:        spec.Name,
+			Description: spec.Description,
+			External:    spec.External,
+			Shape:       shapes[spec.Shape],
+			Sprite:      spec.Sprite,
+			Properties:  spec.Properties,
+			Tags:        spec.Tags,
+		})
+	case c4.KindContainer:
+		return c4.NewContainer(ctx, spec.ID, c4.ContainerArgs{
+			Name:         spec.Name,
+			Description:  spec.Description,
+			Technologies: spec.Technologies,
+			External:     spec.External,
+			Sprite:       spec.Sprite,
+			Properties:   spec.Properties,
+			Tags:         spec.Tags,
+		})
+	case c4.KindComponent:
+		return c4.NewComponent(ctx, spec.ID, c4.ComponentArgs{
+			Name:         spec.Name,
+			Description:  spec.Description,
+			Technologies: spec.Technologies,
+			External:     spec.External,
+			Shape:        shapes[spec.Shape],
+			Sprite:       spec.Sprite,
+			Properties:   spec.Properties,
+			Tags:         spec.Tags,
+		})
+	case c4.KindDatabase:
+		return c4.NewDatabase(ctx, spec.ID, c4.DatabaseArgs{
+			Name:         spec.Name,
+			Description:  spec.Description,
+			Technologies: spec.Technologies,
+			External:     spec.External,
+			Sprite:       spec.Sprite,
+			Properties:   spec.Properties,
+			Tags:         spec.Tags,
+		})
+	case c4.KindQueue:
+		return c4.NewQueue(ctx, spec.ID, c4.QueueArgs{
+			Name:         spec.Name,
+			Description:  spec.Description,
+			Technologies: spec.Technologies,
+			External:     spec.External,
+			Sprite:       spec.Sprite,
+			Properties:   spec.Properties,
+			Tags:         spec.Tags,
+		})
+	case c4.KindDeploymentNode:
+		return c4.NewDeploymentNode(ctx, spec.ID, c4.DeploymentNodeArgs{
+			Name:        spec.Name,
+			Type:        spec.Type,
+			Description: spec.Description,
+			Properties:  spec.Properties,
+			Sprite:      spec.Sprite,
+			Instances:   spec.Instances,
+			Alignment:   alignments[spec.Alignment],
+			Tags:        spec.Tags,
+		})
+	case c4.KindInfrastructureNode:
+		return c4.NewInfrastructureNode(ctx, spec.ID, c4.InfrastructureNodeArgs{
+			Name:         spec.Name,
+			Description:  spec.Description,
+			Technologies: spec.Technologies,
+			Sprite:       spec.Sprite,
+			Properties:   spec.Properties,
+			Tags:         spec.Tags,
+		})
+	case c4.KindEnterpriseBoundary:
+		return c4.NewEnterpriseBoundary(ctx, spec.ID, c4.EnterpriseBoundaryArgs{
+			Name: spec.Name,
+			Tags: spec.Tags,
+		})
+	}
+	return nil, fmt.Errorf("model: invalid kind %q for %s", spec.Kind, spec.ID)
+}
@@ -0,0 +1,176 @@
+package model
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/haleyrc/c4"
+)
+
+// file is the top-level structure of a model file.
+type file struct {
+	Title     string          `yaml:"title"`
+	Elements  []*elementSpec  `yaml:"elements"`
+	Relations []*relationSpec `yaml:"relations"`
+	Views     []*viewSpec     `yaml:"views"`
+}
+
+type elementSpec struct {
+	ID           string     `yaml:"id"`
+	Kind         c4.Kind    `yaml:"kind"`
+	Name         string     `yaml:"name"`
+	Description  string     `yaml:"description"`
+	Technologies []string   `yaml:"technologies"`
+	External     bool       `yaml:"external"`
+	Shape        string     `yaml:"shape"`
+	Sprite       string     `yaml:"sprite"`
+	Properties   properties `yaml:"properties"`
+	Tags         []string   `yaml:"tags"`
+
+	// Deployment nodes
+	Type      string `yaml:"type"`
+	Instances int    `yaml:"instances"`
+	Alignment string `yaml:"alignment"`
+
+	// Instances
+	Of string `yaml:"of"`
+
+	Elements []*elementSpec `yaml:"elements"`
+
+	line int
+}
+
+func (s *elementSpec) UnmarshalYAML(n *yaml.Node) error {
+	type plain elementSpec
+	if err := n.Decode((*plain)(s)); err != nil {
+		return err
+	}
+	s.line = n.Line
+	return nil
+}
+
+type relationSpec struct {
+	Src          string     `yaml:"src"`
+	Dst          string     `yaml:"dst"`
+	Description  string     `yaml:"description"`
+	Technologies []string   `yaml:"technologies"`
+	Direction    string     `yaml:"direction"`
+	Properties   properties `yaml:"properties"`
+
+	line int
+}
+
+func (s *relationSpec) UnmarshalYAML(n *yaml.Node) error {
+	type plain relationSpec
+	if err := n.Decode((*plain)(s)); err != nil {
+		return err
+	}
+	s.line = n.Line
+	return nil
+}
+
+type viewSpec struct {
+	Key               string    `yaml:"key"`
+	Title             string    `yaml:"title"`
+	Include           []string  `yaml:"include"`
+	Exclude           []string  `yaml:"exclude"`
+	Layout            string    `yaml:"layout"`
+	Legend            bool      `yaml:"legend"`
+	Sketch            bool      `yaml:"sketch"`
+	HideElementTypes  bool      `yaml:"hideElementTypes"`
+	InstanceRelations bool      `yaml:"instanceRelations"`
+	Sprites           []string  `yaml:"sprites"`
+	Focus             string    `yaml:"focus"`
+	Depth             *int      `yaml:"depth"`
+	Follow            string    `yaml:"follow"`
+	IncludeTags       []string  `yaml:"includeTags"`
+	ExcludeTags       []string  `yaml:"excludeTags"`
+	ExcludeExternal   bool      `yaml:"excludeExternal"`
+	Types             []c4.Kind `yaml:"types"`
+
+	line int
+}
+
+func (s *viewSpec) UnmarshalYAML(n *yaml.Node) error {
+	type plain viewSpec
+	if err := n.Decode((*plain)(s)); err != nil {
+		return err
+	}
+	s.line = n.Line
+	return nil
+}
+
+// properties are written as a mapping, but decoded in declaration order.
+type properties []c4.Property
+
+func (p *properties) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: properties must be a mapping", n.Line)
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		var value string
+		if err := n.Content[i+1].Decode(&value); err != nil {
+			return err
+		}
+		*p = append(*p, c4.Property{Name: n.Content[i].Value, Value: value})
+	}
+	return nil
+}
+
+var shapes = map[string]c4.Shape{
+	"":         c4.ShapeDefault,
+	"default":  c4.ShapeDefault,
+	"database": c4.ShapeDatabase,
+	"queue":    c4.ShapeQueue,
+}
+
+var alignments = map[string]c4.Alignment{
+	"":       c4.AlignmentCenter,
+	"center": c4.AlignmentCenter,
+	"left":   c4.AlignmentLeft,
+	"right":  c4.AlignmentRight,
+}
+
+var directions = map[string]c4.Direction{
+	"":      "",
+	"up":    c4.DirectionUp,
+	"down":  c4.DirectionDown,
+	"left":  c4.DirectionLeft,
+	"right": c4.DirectionRight,
+}
+
+var follows = map[string][]c4.FocusOption{
+	"":         nil,
+	"both":     nil,
+	"inbound":  {c4.FocusInbound()},
+	"outbound": {c4.FocusOutbound()},
+}
+
+var layouts = map[string]c4.Layout{
+	"":           c4.DefaultLayout,
+	"top-down":   c4.LayoutTopDown,
+	"landscape":  c4.LayoutLandscape,
+	"left-right": c4.LayoutLeftRight,
+}
+
+var sprites = map[string]c4.SpriteLibrary{
+	string(c4.SpritesAWS):         c4.SpritesAWS,
+	string(c4.SpritesAzure):       c4.SpritesAzure,
+	string(c4.SpritesGCP):         c4.SpritesGCP,
+	string(c4.SpritesKubernetes):  c4.SpritesKubernetes,
+	string(c4.SpritesDevicons):    c4.SpritesDevicons,
+	string(c4.SpritesFontAwesome): c4.SpritesFontAwesome,
+}
+
+// children lists the kinds of element that may be nested within each kind.
+// Systems, containers and databases with children are displayed as
+// boundaries when their children are included in a view.
+var children = map[c4.Kind][]c4.Kind{
+	"":                        {c4.KindPerson, c4.KindSystem, c4.KindContainer, c4.KindComponent, c4.KindDatabase, c4.KindQueue, c4.KindDeploymentNode, c4.KindInfrastructureNode, c4.KindEnterpriseBoundary},
+	c4.KindEnterpriseBoundary: {c4.KindPerson, c4.KindSystem},
+	c4.KindSystem:             {c4.KindContainer, c4.KindDatabase, c4.KindQueue},
+	c4.KindContainer:          {c4.KindComponent},
+	c4.KindDatabase:           {c4.KindComponent},
+	c4.KindDeploymentNode:     {c4.KindDeploymentNode, c4.KindInfrastructureNode, c4.KindInstance, c4.KindSystem, c4.KindContainer, c4.KindDatabase, c4.KindQueue},
+}
@@ -0,0 +1,487 @@
+// Package openapi builds c4 components and relations from OpenAPI 3
+// documents.
+//
+// The operations in a document are grouped by tag, or by the x-controller
+// extension, and each group becomes a component within the boundary of the
+// container that serves the API. Relation arguments describing calls to the
+// API can also be generated so that the technologies and operations shown on
+// the diagram match the specification:
+//
+//	spec, err := openapi.LoadFile(ctx, "openapi.yaml")
+//	if err != nil {
+//		return err
+//	}
+//
+//	res, err := spec.Import(ctx, openapi.ImportArgs{Container: apiApplication})
+//	if err != nil {
+//		return err
+//	}
+//	d.AddElement(ctx, res.Boundary)
+//
+//	d.NewRelation(ctx, spec.RelationArgs(singlePageApplication, apiApplication, "Makes API calls to"))
+package openapi
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/haleyrc/c4"
+	"github.com/haleyrc/c4/internal/alias"
+)
+
+// Grouping controls how operations are grouped into components.
+type Grouping int
+
+const (
+	// GroupByTag creates a component per tag. Operations with multiple tags
+	// belong to the first.
+	GroupByTag Grouping = iota
+
+	// GroupByController creates a component per value of the x-controller
+	// extension on operations, which some frameworks use to record the
+	// handling class.
+	GroupByController
+)
+
+// DefaultGroup is the group used for operations without a tag or controller.
+const DefaultGroup = "default"
+
+// methods lists the operation fields of a path item in display order.
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// Spec holds the parts of an OpenAPI document used to build elements.
+type Spec struct {
+	doc        document
+	operations []operation
+}
+
+type document struct {
+	OpenAPI string `yaml:"openapi"`
+	Servers []struct {
+		URL string `yaml:"url"`
+	} `yaml:"servers"`
+	Tags []struct {
+		Name        string `yaml:"name"`
+		Description string `yaml:"description"`
+	} `yaml:"tags"`
+	Paths map[string]map[string]yaml.Node `yaml:"paths"`
+}
+
+type operation struct {
+	Method      string
+	Path        string
+	Tags        []string             `yaml:"tags"`
+	Controller  string               `yaml:"x-controller"`
+	RequestBody yaml.Node            `yaml:"requestBody"`
+	Responses   map[string]yaml.Node `yaml:"responses"`
+	Parameters  []yaml.Node          `yaml:"parameters"`
+
+	// The media types of the request body, responses and parameters, with
+	// any references resolved.
+	mediaTypes []string
+}
+
+// LoadFile is the same as Load, but reads the named document.
+func LoadFile(ctx context.Context, name string) (*Spec, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("openapi: load: %w", err)
+	}
+	defer f.Close()
+
+	return Load(ctx, f)
+}
+
+// Load reads an OpenAPI 3 document in either YAML or JSON form from r. Request
+// bodies, responses and parameters may refer to the components of the same
+// document using $ref. References to other documents aren't followed.
+func Load(ctx context.Context, r io.Reader) (*Spec, error) {
+	var root yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&root); err != nil {
+		return nil, fmt.Errorf("openapi: load: %w", err)
+	}
+	var doc document
+	if err := root.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("openapi: load: %w", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("openapi: load: unsupported version %q", doc.OpenAPI)
+	}
+
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	s := &Spec{doc: doc}
+	for _, path := range paths {
+		item := doc.Paths[path]
+		for _, method := range methods {
+			node, ok := item[method]
+			if !ok {
+				continue
+			}
+			var op operation
+			if err := node.Decode(&op); err != nil {
+				return nil, fmt.Errorf("openapi: load: %s %s: %w", strings.ToUpper(method), path, err)
+			}
+			op.Method = strings.ToUpper(method)
+			op.Path = path
+
+			// Parameters may be shared by every operation on a path.
+			var shared []yaml.Node
+			if node, ok := item["parameters"]; ok {
+				if err := node.Decode(&shared); err != nil {
+					return nil, fmt.Errorf("openapi: load: %s parameters: %w", path, err)
+				}
+			}
+
+			mediaTypes, err := op.resolveMediaTypes(root.Content[0], shared)
+			if err != nil {
+				return nil, fmt.Errorf("openapi: load: %s %s: %w", op.Method, path, err)
+			}
+			op.mediaTypes = mediaTypes
+			s.operations = append(s.operations, op)
+		}
+	}
+
+	return s, nil
+}
+
+// ImportArgs describes the parameters available for importing components.
+type ImportArgs struct {
+	// The container serving the API. The generated components are added to
+	// its boundary.
+	Container *c4.Container
+
+	// How operations are grouped into components.
+	GroupBy Grouping
+
+	// An optional list of technologies for the components e.g. Spring MVC Rest
+	// Controller. Defaults to the technologies of the operations in each
+	// group e.g. JSON/HTTPS.
+	Technologies []string
+}
+
+// Result holds the elements generated from a document.
+type Result struct {
+	// The boundary of the container containing the components.
+	Boundary c4.Boundary
+
+	// One component per group in the order the groups are declared.
+	Components []*c4.Component
+
+	// The components keyed by tag or controller name.
+	Groups map[string]*c4.Component
+}
+
+// Import creates a component for each group of operations in the document.
+func (s *Spec) Import(ctx context.Context, args ImportArgs) (*Result, error) {
+	if args.Container == nil {
+		return nil, fmt.Errorf("openapi: import: a container is required")
+	}
+
+	res := &Result{
+		Boundary: args.Container.Boundary(),
+		Groups:   map[string]*c4.Component{},
+	}
+
+	for _, group := range s.groups(args.GroupBy) {
+		ops := s.groupOperations(args.GroupBy, group)
+
+		technologies := args.Technologies
+		if len(technologies) == 0 {
+			technologies = s.technologies(ops)
+		}
+
+		c, err := c4.NewComponent(ctx, alias.Make(args.Container.ID(), group), c4.ComponentArgs{
+			Name:         group,
+			Description:  s.tagDescription(group),
+			Technologies: technologies,
+			Properties:   []c4.Property{operationsProperty(ops)},
+		})
+		if err != nil {
+			return nil, err
+		}
+		res.Boundary.AddElement(ctx, c)
+		res.Components = append(res.Components, c)
+		res.Groups[group] = c
+	}
+
+	return res, nil
+}
+
+// RelationArgs returns the arguments for a relation from src to dst that calls
+// the API. The technologies are derived from the media types and server URLs
+// in the document e.g. "JSON/HTTPS", and the operations called are attached as
+// a property. If groups are given, only operations in those groups are
+// included.
+func (s *Spec) RelationArgs(src, dst c4.Element, description string, groups ...string) c4.RelationArgs {
+	ops := s.operations
+	if len(groups) > 0 {
+		ops = nil
+		for _, op := range s.operations {
+			for _, group := range groups {
+				if op.group(GroupByTag) == group || op.group(GroupByController) == group {
+					ops = append(ops, op)
+					break
+				}
+			}
+		}
+	}
+
+	return c4.RelationArgs{
+		Src:          src,
+		Dst:          dst,
+		Description:  description,
+		Technologies: s.technologies(ops),
+		Properties:   []c4.Property{operationsProperty(ops)},
+	}
+}
+
+func (op operation) group(by Grouping) string {
+	switch by {
+	case GroupByController:
+		if op.Controller != "" {
+			return op.Controller
+		}
+	default:
+		if len(op.Tags) > 0 {
+			return op.Tags[0]
+		}
+	}
+	return DefaultGroup
+}
+
+// groups returns the names of the groups in the document. Declared tags come
+// first in declaration order followed by any undeclared groups in the order
+// they are used.
+func (s *Spec) groups(by Grouping) []string {
+	used := map[string]bool{}
+	for _, op := range s.operations {
+		used[op.group(by)] = true
+	}
+
+	var groups []string
+	seen := map[string]bool{}
+	if by == GroupByTag {
+		for _, tag := range s.doc.Tags {
+			if used[tag.Name] && !seen[tag.Name] {
+				seen[tag.Name] = true
+				groups = append(groups, tag.Name)
+			}
+		}
+	}
+	for _, op := range s.operations {
+		if g := op.group(by); !seen[g] {
+			seen[g] = true
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
+
+func (s *Spec) groupOperations(by Grouping, group string) []operation {
+	var ops []operation
+	for _, op := range s.operations {
+		if op.group(by) == group {
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
+
+func (s *Spec) tagDescription(name string) string {
+	for _, tag := range s.doc.Tags {
+		if tag.Name == name {
+			return tag.Description
+		}
+	}
+	return ""
+}
+
+// technologies describes the API as "<format>/<protocol>" e.g. "JSON/HTTPS"
+// using the media types of the given operations and the server URLs.
+func (s *Spec) technologies(ops []operation) []string {
+	var formats []string
+	seen := map[string]bool{}
+	addFormat := func(mediaType string) {
+		f := format(mediaType)
+		if f != "" && !seen[f] {
+			seen[f] = true
+			formats = append(formats, f)
+		}
+	}
+	for _, op := range ops {
+		for _, mt := range op.mediaTypes {
+			addFormat(mt)
+		}
+	}
+	sort.Strings(formats)
+
+	protocol := "HTTP"
+	for _, server := range s.doc.Servers {
+		if u, err := url.Parse(server.URL); err == nil && u.Scheme == "https" {
+			protocol = "HTTPS"
+			break
+		}
+	}
+
+	if len(formats) == 0 {
+		return []string{protocol}
+	}
+	technologies := make([]string, 0, len(formats))
+	for _, f := range formats {
+		technologies = append(technologies, f+"/"+protocol)
+	}
+	return technologies
+}
+
+// format returns the short name of a media type e.g. "JSON" for both
+// "application/json" and "application/problem+json".
+func format(mediaType string) string {
+	mt, _, _ := strings.Cut(mediaType, ";")
+	mt = strings.ToLower(strings.TrimSpace(mt))
+	if _, suffix, ok := strings.Cut(mt, "+"); ok {
+		return strings.ToUpper(suffix)
+	}
+	switch mt {
+	case "application/json", "text/json":
+		return "JSON"
+	case "application/xml", "text/xml":
+		return "XML"
+	case "application/x-protobuf", "application/protobuf":
+		return "Protobuf"
+	case "application/x-www-form-urlencoded", "multipart/form-data":
+		return "Form"
+	case "text/plain":
+		return "Text"
+	case "text/html":
+		return "HTML"
+	case "*/*", "":
+		return ""
+	}
+	_, subtype, _ := strings.Cut(mt, "/")
+	return strings.ToUpper(subtype)
+}
+
+// resolveMediaTypes returns the media types of the request body, responses
+// and parameters of the operation, along with those of the parameters shared
+// by its path. References are resolved against root, the top-level mapping of
+// the document.
+func (op operation) resolveMediaTypes(root *yaml.Node, shared []yaml.Node) ([]string, error) {
+	nodes := []*yaml.Node{&op.RequestBody}
+	for _, code := range sortedKeys(op.Responses) {
+		resp := op.Responses[code]
+		nodes = append(nodes, &resp)
+	}
+	for i := range shared {
+		nodes = append(nodes, &shared[i])
+	}
+	for i := range op.Parameters {
+		nodes = append(nodes, &op.Parameters[i])
+	}
+
+	var mediaTypes []string
+	for _, node := range nodes {
+		node, err := resolve(root, node)
+		if err != nil {
+			return nil, err
+		}
+		content, err := resolve(root, child(node, "content"))
+		if err != nil {
+			return nil, err
+		}
+		if content == nil || content.Kind != yaml.MappingNode {
+			continue
+		}
+		for i := 0; i < len(content.Content); i += 2 {
+			mediaTypes = append(mediaTypes, content.Content[i].Value)
+		}
+	}
+	return mediaTypes, nil
+}
+
+// resolve returns the node referred to by node if it is a reference e.g.
+// {$ref: "#/components/responses/NotFound"}, following any further references
+// in turn. Nodes that aren't references are returned as they are, while
+// references to other documents resolve to nil.
+func resolve(root, node *yaml.Node) (*yaml.Node, error) {
+	seen := map[string]bool{}
+	for {
+		ref := child(node, "$ref")
+		if ref == nil {
+			return node, nil
+		}
+		if !strings.HasPrefix(ref.Value, "#/") {
+			return nil, nil
+		}
+		if seen[ref.Value] {
+			return nil, fmt.Errorf("circular reference %s", ref.Value)
+		}
+		seen[ref.Value] = true
+
+		// The reference is a JSON pointer within a URI fragment, so it is
+		// percent-encoded in addition to the escaping of "~" and "/".
+		node = root
+		for _, token := range strings.Split(strings.TrimPrefix(ref.Value, "#/"), "/") {
+			if unescaped, err := url.PathUnescape(token); err == nil {
+				token = unescaped
+			}
+			token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+			if node = child(node, token); node == nil {
+				return nil, fmt.Errorf("cannot resolve %s", ref.Value)
+			}
+		}
+	}
+}
+
+// child returns the value of key in a mapping node, or the element at the
+// index key in a sequence node, if present.
+func child(node *yaml.Node, key string) *yaml.Node {
+	if node != nil && node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node == nil {
+		return nil
+	}
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				return node.Content[i+1]
+			}
+		}
+	case yaml.SequenceNode:
+		if i, err := strconv.Atoi(key); err == nil && i >= 0 && i < len(node.Content) {
+			return node.Content[i]
+		}
+	}
+	return nil
+}
+
+func operationsProperty(ops []operation) c4.Property {
+	names := make([]string, 0, len(ops))
+	for _, op := range ops {
+		names = append(names, op.Method+" "+op.Path)
+	}
+	return c4.Property{Name: "Operations", Value: strings.Join(names, ", ")}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
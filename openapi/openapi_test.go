@@ -0,0 +1,103 @@
+package openapi
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/haleyrc/c4"
+)
+
+// components describes the components in res, one per line, by their name,
+// technologies and operations e.g. "orders JSON/HTTPS GET /orders".
+func components(res *Result) string {
+	var lines []string
+	for _, c := range res.Components {
+		line := c.Name() + " " + strings.Join(c.Technologies(), ", ")
+		for _, p := range c.Properties() {
+			line += " " + p.Value
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+	api := c4.MustNewContainer(ctx, "api", c4.ContainerArgs{Name: "API"})
+
+	tests := []struct {
+		by   Grouping
+		want []string
+	}{
+		{
+			by: GroupByTag,
+			want: []string{
+				"orders JSON/HTTPS, PDF/HTTPS GET /orders, POST /orders, GET /orders/{id}/invoice",
+				"customers Text/HTTPS, XML/HTTPS GET /customers",
+				"default HTTPS GET /health",
+			},
+		},
+		{
+			by: GroupByController,
+			want: []string{
+				"default Text/HTTPS, XML/HTTPS GET /customers, GET /health",
+				"OrderController JSON/HTTPS GET /orders, POST /orders",
+				"InvoiceController PDF/HTTPS GET /orders/{id}/invoice",
+			},
+		},
+	}
+	// Both documents describe the same API, but refs.yaml declares its
+	// request bodies, responses and parameters as components.
+	for _, file := range []string{"inline.yaml", "refs.yaml"} {
+		spec, err := LoadFile(ctx, filepath.Join("testdata", file))
+		if err != nil {
+			t.Fatalf("LoadFile(%s): %v", file, err)
+		}
+		for _, tt := range tests {
+			res, err := spec.Import(ctx, ImportArgs{Container: api, GroupBy: tt.by})
+			if err != nil {
+				t.Fatalf("Import(%s): %v", file, err)
+			}
+			if got, want := components(res), strings.Join(tt.want, "\n"); got != want {
+				t.Errorf("Import(%s) with grouping %d got:\n%s\nwant:\n%s", file, tt.by, got, want)
+			}
+		}
+
+		args := spec.RelationArgs(api, api, "Uses", "customers")
+		if got, want := strings.Join(args.Technologies, ", "), "Text/HTTPS, XML/HTTPS"; got != want {
+			t.Errorf("RelationArgs(%s) got technologies %q, want %q", file, got, want)
+		}
+	}
+}
+
+func TestLoadInvalidReference(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"#/components/responses/Missing", "cannot resolve #/components/responses/Missing"},
+		{"#/components/responses/Loop", "circular reference #/components/responses/Loop"},
+	}
+	for _, tt := range tests {
+		src := `
+openapi: 3.0.3
+paths:
+  /orders:
+    get:
+      responses:
+        "200":
+          $ref: "` + tt.ref + `"
+components:
+  responses:
+    Loop:
+      $ref: "#/components/responses/Loop"
+`
+		_, err := Load(ctx, strings.NewReader(src))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Load with %s got error %v, want %q", tt.ref, err, tt.want)
+		}
+	}
+}
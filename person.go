@@ -14,6 +14,18 @@ type PersonArgs struct {
 
 	// Enables alternate styling reserved for external elements.
 	External bool
+
+	// An optional sprite to display on the person e.g. "font-awesome/user". See
+	// SpriteLibrary for the available libraries.
+	Sprite string
+
+	// An optional list of properties describing the person e.g. an owner or
+	// SLA. These are displayed as a table within the person.
+	Properties []Property
+
+	// An optional list of tags classifying the person e.g. "pci". Diagrams can
+	// be filtered by tag using Diagram.Filter.
+	Tags []string
 }
 
 // MustNewPerson is the same as NewPerson, but panics on any error.
@@ -32,6 +44,9 @@ func NewPerson(ctx context.Context, id string, args PersonArgs) (*Person, error)
 		name:        args.Name,
 		description: args.Description,
 		external:    args.External,
+		sprite:      args.Sprite,
+		properties:  args.Properties,
+		tags:        args.Tags,
 	}
 	return p, nil
 }
@@ -43,7 +58,28 @@ type Person struct {
 	name        string
 	description string
 	external    bool
+	sprite      string
+	tags        []string
+	properties  []Property
 }
 
 // ID satisfies the Element interface.
 func (p *Person) ID() string { return p.id }
+
+// Name returns the human-readable name of the person.
+func (p *Person) Name() string { return p.name }
+
+// Description returns the description of the person.
+func (p *Person) Description() string { return p.description }
+
+// External reports whether the person is styled as an external element.
+func (p *Person) External() bool { return p.external }
+
+// Sprite returns the sprite displayed on the person, if any.
+func (p *Person) Sprite() string { return p.sprite }
+
+// Properties returns the properties describing the person.
+func (p *Person) Properties() []Property { return p.properties }
+
+// Tags returns the tags classifying the person.
+func (p *Person) Tags() []string { return p.tags }
@@ -1,110 +1,126 @@
 package c4
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"strings"
 )
 
+// C4-PlantUML has no dedicated macro for infrastructure nodes, so they are
+// displayed as childless nodes distinguished by a custom tag.
+const infrastructureNodeTag = "infrastructure_node"
+
 func plantUML(ctx context.Context, w io.Writer, el interface{}) error {
 	switch v := el.(type) {
 	case *Component:
-		prefix := "Component"
+		writePlantUMLProperties(w, v.properties)
+		prefix := "Component" + string(v.shape)
 		if v.external {
 			prefix += "_Ext"
 		}
 		technologies := strings.Join(v.technologies, ", ")
-		fmt.Fprintf(w, `%s(%s, "%s", "%s", "%s")`, prefix, v.ID(), v.name, technologies, v.description)
+		fmt.Fprintf(w, `%s(%s, "%s", "%s", "%s"%s%s)`, prefix, v.ID(), v.name, technologies, v.description, spriteArg(v.sprite), tagsArg(v.tags))
 		fmt.Fprintln(w)
 	case *Container:
+		writePlantUMLProperties(w, v.properties)
 		prefix := "Container"
 		if v.external {
 			prefix += "_Ext"
 		}
 		technologies := strings.Join(v.technologies, ", ")
-		fmt.Fprintf(w, `%s(%s, "%s", "%s", "%s")`, prefix, v.ID(), v.name, technologies, v.description)
+		fmt.Fprintf(w, `%s(%s, "%s", "%s", "%s"%s%s)`, prefix, v.ID(), v.name, technologies, v.description, spriteArg(v.sprite), tagsArg(v.tags))
 		fmt.Fprintln(w)
 	case *containerBoundary:
-		fmt.Fprintf(w, `Container_Boundary(%s, "%s") {`, v.ID(), v.name)
+		fmt.Fprintf(w, `Container_Boundary(%s, "%s"%s) {`, v.ID(), v.name, tagsArg(v.tags))
 		fmt.Fprintln(w)
-		for _, el := range v.elements {
-			fmt.Fprintf(w, "\t")
-			if err := plantUML(ctx, w, el); err != nil {
-				return err
-			}
+		if err := writePlantUMLChildren(ctx, w, v.elements); err != nil {
+			return err
+		}
+		fmt.Fprintln(w, "}")
+	case *databaseBoundary:
+		fmt.Fprintf(w, `Container_Boundary(%s, "%s"%s) {`, v.ID(), v.name, tagsArg(v.tags))
+		fmt.Fprintln(w)
+		if err := writePlantUMLChildren(ctx, w, v.elements); err != nil {
+			return err
 		}
 		fmt.Fprintln(w, "}")
 	case *EnterpriseBoundary:
-		fmt.Fprintf(w, `Enterprise_Boundary(%s, "%s") {`, v.ID(), v.name)
+		fmt.Fprintf(w, `Enterprise_Boundary(%s, "%s"%s) {`, v.ID(), v.name, tagsArg(v.tags))
 		fmt.Fprintln(w)
-		for _, el := range v.elements {
-			fmt.Fprintf(w, "\t")
-			if err := plantUML(ctx, w, el); err != nil {
-				return err
-			}
+		if err := writePlantUMLChildren(ctx, w, v.elements); err != nil {
+			return err
 		}
 		fmt.Fprintln(w, "}")
 	case *Database:
+		writePlantUMLProperties(w, v.properties)
 		prefix := "ContainerDb"
 		if v.external {
 			prefix += "_Ext"
 		}
 		technologies := strings.Join(v.technologies, ", ")
-		fmt.Fprintf(w, `%s(%s, "%s", "%s", "%s")`, prefix, v.ID(), v.name, technologies, v.description)
+		fmt.Fprintf(w, `%s(%s, "%s", "%s", "%s"%s%s)`, prefix, v.ID(), v.name, technologies, v.description, spriteArg(v.sprite), tagsArg(v.tags))
 		fmt.Fprintln(w)
 	case *DeploymentNode:
-		for _, property := range v.properties {
-			fmt.Fprintf(w, `AddProperty("%s", "%s")`, property.Name, property.Value)
-			fmt.Fprintln(w)
+		writePlantUMLProperties(w, v.properties)
+		prefix := "Deployment_Node"
+		if v.alignment != AlignmentCenter {
+			prefix = "Node" + string(v.alignment)
 		}
-		fmt.Fprintf(w, `Deployment_Node(%s, "%s", "%s", "%s") {`, v.id, v.name, v.nodeType, v.description)
+		fmt.Fprintf(w, `%s(%s, "%s", "%s", "%s"%s%s) {`, prefix, v.id, v.label(), v.nodeType, v.description, spriteArg(v.sprite), tagsArg(v.tags))
 		fmt.Fprintln(w)
-		for _, el := range v.elements {
-			fmt.Fprintf(w, "\t")
-			if err := plantUML(ctx, w, el); err != nil {
-				return err
-			}
+		if err := writePlantUMLChildren(ctx, w, v.elements); err != nil {
+			return err
 		}
 		fmt.Fprintln(w, `}`)
+	case *InfrastructureNode:
+		writePlantUMLProperties(w, v.properties)
+		technologies := strings.Join(v.technologies, ", ")
+		fmt.Fprintf(w, `Node(%s, "%s", "%s", "%s"%s%s)`, v.ID(), v.name, technologies, v.description, spriteArg(v.sprite), tagsArg(append([]string{infrastructureNodeTag}, v.tags...)))
+		fmt.Fprintln(w)
+	case *Instance:
+		return plantUML(ctx, w, v.element())
 	case *Person:
+		writePlantUMLProperties(w, v.properties)
 		prefix := "Person"
 		if v.external {
 			prefix += "_Ext"
 		}
-		fmt.Fprintf(w, `%s(%s, "%s", "%s")`, prefix, v.ID(), v.name, v.description)
+		fmt.Fprintf(w, `%s(%s, "%s", "%s"%s%s)`, prefix, v.ID(), v.name, v.description, spriteArg(v.sprite), tagsArg(v.tags))
 		fmt.Fprintln(w)
 	case *Queue:
+		writePlantUMLProperties(w, v.properties)
 		prefix := "ContainerQueue"
 		if v.external {
 			prefix += "_Ext"
 		}
 		technologies := strings.Join(v.technologies, ", ")
-		fmt.Fprintf(w, `%s(%s, "%s", "%s", "%s")`, prefix, v.ID(), v.name, technologies, v.description)
+		fmt.Fprintf(w, `%s(%s, "%s", "%s", "%s"%s%s)`, prefix, v.ID(), v.name, technologies, v.description, spriteArg(v.sprite), tagsArg(v.tags))
 		fmt.Fprintln(w)
 	case *relation:
+		writePlantUMLProperties(w, v.properties)
 		prefix := "Rel"
 		if v.direction != "" {
 			prefix = fmt.Sprintf("Rel_%s", v.direction)
 		}
-		fmt.Fprintf(w, `%s(%s, %s, "%s", "%s")`, prefix, v.src.ID(), v.dst.ID(), v.description, strings.Join(v.technologies, ","))
+		fmt.Fprintf(w, `%s(%s, %s, "%s", "%s"%s)`, prefix, v.src.ID(), v.dst.ID(), v.description, strings.Join(v.technologies, ","), tagsArg(v.tags))
 		fmt.Fprintln(w)
 	case *systemBoundary:
-		fmt.Fprintf(w, `System_Boundary(%s, "%s") {`, v.ID(), v.name)
+		fmt.Fprintf(w, `System_Boundary(%s, "%s"%s) {`, v.ID(), v.name, tagsArg(v.tags))
 		fmt.Fprintln(w)
-		for _, el := range v.elements {
-			fmt.Fprintf(w, "\t")
-			if err := plantUML(ctx, w, el); err != nil {
-				return err
-			}
+		if err := writePlantUMLChildren(ctx, w, v.elements); err != nil {
+			return err
 		}
 		fmt.Fprintln(w, "}")
 	case *System:
-		prefix := "System"
+		writePlantUMLProperties(w, v.properties)
+		prefix := "System" + string(v.shape)
 		if v.external {
 			prefix += "_Ext"
 		}
-		fmt.Fprintf(w, `%s(%s, "%s", "%s")`, prefix, v.ID(), v.name, v.description)
+		fmt.Fprintf(w, `%s(%s, "%s", "%s"%s%s)`, prefix, v.ID(), v.name, v.description, spriteArg(v.sprite), tagsArg(v.tags))
 		fmt.Fprintln(w)
 	default:
 		return fmt.Errorf("cannot create plantuml: invalid item type: %T", el)
@@ -112,3 +128,37 @@ func plantUML(ctx context.Context, w io.Writer, el interface{}) error {
 
 	return nil
 }
+
+// writePlantUMLChildren writes the elements nested within a boundary, indenting
+// every line they produce.
+func writePlantUMLChildren(ctx context.Context, w io.Writer, els []Element) error {
+	for _, el := range els {
+		var buff bytes.Buffer
+		if err := plantUML(ctx, &buff, el); err != nil {
+			return err
+		}
+		s := bufio.NewScanner(&buff)
+		for s.Scan() {
+			fmt.Fprintf(w, "\t%s\n", s.Text())
+		}
+	}
+	return nil
+}
+
+// writePlantUMLProperties writes the properties table for the element or
+// relation that immediately follows.
+func writePlantUMLProperties(w io.Writer, properties []Property) {
+	for _, property := range properties {
+		fmt.Fprintf(w, `AddProperty("%s", "%s")`, property.Name, property.Value)
+		fmt.Fprintln(w)
+	}
+}
+
+// tagsArg returns the argument applying the given tags to an element, boundary
+// or relation, if there are any.
+func tagsArg(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(`, $tags="%s"`, strings.Join(tags, "+"))
+}
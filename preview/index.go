@@ -0,0 +1,57 @@
+package preview
+
+import "html/template"
+
+type indexData struct {
+	Title   string
+	Version int
+	Errors  []string
+	Views   []*view
+}
+
+func (s *snapshot) data() indexData {
+	return indexData{Title: s.title, Version: s.version, Errors: s.errors, Views: s.views}
+}
+
+// indexTemplate displays every view. The page listens for new versions using
+// server-sent events, falling back to polling in browsers without them, and
+// reloads itself when the version changes.
+var indexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; margin: 2rem; color: #262626; }
+nav a { margin-right: 1rem; }
+section { margin: 2rem 0; }
+.error { background: #fdecea; border-left: 4px solid #d93025; padding: 0.5rem 1rem; white-space: pre-wrap; }
+img { max-width: 100%; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{range .Errors}}<pre class="error">{{.}}</pre>
+{{end}}
+{{- if .Views}}<nav>{{range .Views}}<a href="#{{.Key}}">{{.Title}}</a>{{end}}</nav>
+{{end}}
+{{- range .Views}}
+<section id="{{.Key}}">
+<h2>{{.Title}}</h2>
+{{range .Errors}}<pre class="error">{{.}}</pre>
+{{end}}
+{{- if .SVG}}<img src="views/{{.Key}}.svg?v={{$.Version}}" alt="{{.Title}}">{{end}}
+</section>
+{{- end}}
+<script>
+const version = "{{.Version}}";
+const check = (v) => { if (String(v).trim() !== version) location.reload(); };
+if (window.EventSource) {
+	new EventSource("events").addEventListener("version", (e) => check(e.data));
+} else {
+	setInterval(() => fetch("version").then((r) => r.text()).then(check), 1000);
+}
+</script>
+</body>
+</html>
+`))
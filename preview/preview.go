@@ -0,0 +1,366 @@
+// Package preview serves live previews of the views in a model.
+//
+// A Handler renders every view in a model file as SVG and serves them on an
+// index page. While Watch is running, the model file is checked for changes
+// and the views are rendered again whenever it is saved. Open pages are told
+// to reload using server-sent events, so layout changes such as relation
+// directions can be iterated on without leaving the browser.
+//
+//	r, _ := render.NewPlantUML(ctx, render.PlantUMLArgs{Format: render.FormatSVG})
+//	h, err := preview.NewHandler(ctx, preview.HandlerArgs{
+//		Model:    "bigbank.yaml",
+//		Renderer: r,
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	go h.Watch(ctx)
+//	return http.ListenAndServe("localhost:8080", h)
+package preview
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/haleyrc/c4"
+	"github.com/haleyrc/c4/model"
+	"github.com/haleyrc/c4/render"
+)
+
+// DefaultInterval is how often the model file is checked for changes.
+const DefaultInterval = 500 * time.Millisecond
+
+// keepAlive is how often a comment is sent to idle event streams so that
+// proxies don't close them.
+const keepAlive = 15 * time.Second
+
+type HandlerArgs struct {
+	// The path to the model file.
+	Model string
+
+	// The renderer used to produce the images for each view. The renderer
+	// must produce SVG images.
+	Renderer render.Renderer
+
+	// How often to check the model file for changes. Defaults to
+	// DefaultInterval.
+	Interval time.Duration
+}
+
+// Handler is an http.Handler serving previews of the views in a model. It
+// serves the following routes:
+//
+//	GET /                  an index page displaying every view
+//	GET /views/{key}.svg   the image for a single view
+//	GET /events            a stream of server-sent events announcing changes
+//	GET /version           the current version, for clients that poll
+type Handler struct {
+	name     string
+	renderer render.Renderer
+	interval time.Duration
+	mux      *http.ServeMux
+
+	mu      sync.Mutex
+	current *snapshot
+	subs    map[chan int]struct{}
+	modTime time.Time
+	size    int64
+}
+
+// snapshot is the result of loading and rendering the model.
+type snapshot struct {
+	version int
+	title   string
+	views   []*view
+
+	// Problems that prevented the model from loading.
+	errors []string
+}
+
+type view struct {
+	Key    string
+	Title  string
+	SVG    []byte
+	Errors []string
+}
+
+// NewHandler returns a handler for the model, which is loaded and rendered
+// before it returns. Problems with the model are displayed on the index page
+// rather than returned, so that they can be fixed while the preview is
+// running, but an error is returned if the file can't be read.
+func NewHandler(ctx context.Context, args HandlerArgs) (*Handler, error) {
+	if args.Renderer == nil {
+		return nil, fmt.Errorf("preview: a renderer is required")
+	}
+	h := &Handler{
+		name:     args.Model,
+		renderer: args.Renderer,
+		interval: args.Interval,
+		subs:     map[chan int]struct{}{},
+	}
+	if h.interval <= 0 {
+		h.interval = DefaultInterval
+	}
+
+	if _, err := h.reload(ctx); err != nil {
+		return nil, err
+	}
+
+	h.mux = http.NewServeMux()
+	h.mux.HandleFunc("GET /{$}", h.index)
+	h.mux.HandleFunc("GET /views/{file}", h.image)
+	h.mux.HandleFunc("GET /events", h.events)
+	h.mux.HandleFunc("GET /version", h.version)
+
+	return h, nil
+}
+
+// ServeHTTP satisfies the http.Handler interface.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// Watch checks the model file for changes until the context is done, which
+// causes it to return the context's error. Each change is rendered and
+// announced to any open pages.
+func (h *Handler) Watch(ctx context.Context) error {
+	t := time.NewTicker(h.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+			changed, err := h.reload(ctx)
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				// Editors often replace files when saving them, so a
+				// missing file is assumed to be temporary.
+				changed = h.fail(err)
+			}
+			if changed {
+				h.broadcast()
+			}
+		}
+	}
+}
+
+// reload loads and renders the model if it has changed since it was last
+// loaded, reporting whether it did so.
+func (h *Handler) reload(ctx context.Context) (bool, error) {
+	info, err := os.Stat(h.name)
+	if err != nil {
+		return false, fmt.Errorf("preview: %w", err)
+	}
+
+	h.mu.Lock()
+	unchanged := h.current != nil && info.ModTime().Equal(h.modTime) && info.Size() == h.size
+	h.mu.Unlock()
+	if unchanged {
+		return false, nil
+	}
+
+	snap, err := h.load(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.current != nil {
+		snap.version = h.current.version + 1
+	}
+	h.current = snap
+	h.modTime = info.ModTime()
+	h.size = info.Size()
+
+	return true, nil
+}
+
+// load loads the model and renders each of its views.
+func (h *Handler) load(ctx context.Context) (*snapshot, error) {
+	snap := &snapshot{title: h.name}
+
+	m, err := model.LoadFile(ctx, h.name)
+	var errs model.Errors
+	if errors.As(err, &errs) {
+		for _, e := range errs {
+			snap.errors = append(snap.errors, e.Error())
+		}
+		return snap, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("preview: %w", err)
+	}
+	snap.title = m.Title()
+
+	var ds []*c4.Diagram
+	byDiagram := map[*c4.Diagram]*view{}
+	for _, v := range m.Views() {
+		pv := &view{Key: v.Key, Title: v.Title}
+		snap.views = append(snap.views, pv)
+
+		d, err := m.Diagram(ctx, v.Key)
+		if err == nil {
+			err = d.Validate(ctx)
+		}
+		if err != nil {
+			pv.Errors = append(pv.Errors, err.Error())
+			continue
+		}
+		ds = append(ds, d)
+		byDiagram[d] = pv
+	}
+
+	images, err := render.All(ctx, h.renderer, ds...)
+	var syntaxErrs render.SyntaxErrors
+	switch {
+	case errors.As(err, &syntaxErrs):
+		for _, e := range syntaxErrs {
+			pv := byDiagram[e.Diagram]
+			pv.Errors = append(pv.Errors, e.Error())
+		}
+	case err != nil:
+		// The renderer itself failed, so every view is affected.
+		for _, pv := range byDiagram {
+			pv.Errors = append(pv.Errors, err.Error())
+		}
+		return snap, nil
+	}
+	for i, d := range ds {
+		byDiagram[d].SVG = images[i]
+	}
+
+	return snap, nil
+}
+
+// fail displays an error that prevented the model from being reloaded,
+// reporting whether it wasn't already displayed.
+func (h *Handler) fail(err error) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// The file is loaded again as soon as it changes.
+	h.modTime = time.Time{}
+	h.size = 0
+
+	msg := err.Error()
+	if len(h.current.views) == 0 && len(h.current.errors) == 1 && h.current.errors[0] == msg {
+		return false
+	}
+	h.current = &snapshot{
+		version: h.current.version + 1,
+		title:   h.current.title,
+		errors:  []string{msg},
+	}
+	return true
+}
+
+func (h *Handler) snapshot() *snapshot {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.current
+}
+
+// subscribe returns a channel that receives the version of each change.
+func (h *Handler) subscribe() chan int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ch := make(chan int, 1)
+	h.subs[ch] = struct{}{}
+	return ch
+}
+
+func (h *Handler) unsubscribe(ch chan int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subs, ch)
+}
+
+// broadcast announces the current version to every subscriber. Subscribers
+// that haven't received the previous announcement yet are skipped, as they
+// will reload regardless.
+func (h *Handler) broadcast() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		select {
+		case ch <- h.current.version:
+		default:
+		}
+	}
+}
+
+func (h *Handler) index(w http.ResponseWriter, r *http.Request) {
+	snap := h.snapshot()
+	var buff bytes.Buffer
+	if err := indexTemplate.Execute(&buff, snap.data()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = w.Write(buff.Bytes())
+}
+
+func (h *Handler) image(w http.ResponseWriter, r *http.Request) {
+	snap := h.snapshot()
+	for _, v := range snap.views {
+		if v.Key+".svg" == r.PathValue("file") && v.SVG != nil {
+			w.Header().Set("Content-Type", "image/svg+xml")
+			w.Header().Set("Cache-Control", "no-store")
+			_, _ = w.Write(v.SVG)
+			return
+		}
+	}
+	http.NotFound(w, r)
+}
+
+func (h *Handler) version(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	fmt.Fprint(w, h.snapshot().version)
+}
+
+// events streams the version of the model to the client, starting with the
+// current version so that clients reconnecting after a change reload too.
+func (h *Handler) events(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	ch := h.subscribe()
+	defer h.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Connection", "keep-alive")
+
+	send := func(version int) {
+		fmt.Fprintf(w, "event: version\ndata: %s\n\n", strconv.Itoa(version))
+		flusher.Flush()
+	}
+	send(h.snapshot().version)
+
+	t := time.NewTicker(keepAlive)
+	defer t.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case version := <-ch:
+			send(version)
+		case <-t.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		}
+	}
+}
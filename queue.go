@@ -18,6 +18,19 @@ type QueueArgs struct {
 
 	// Enables alternate styling reserved for external elements.
 	External bool
+
+	// An optional sprite to display on the queue e.g.
+	// "aws/ApplicationIntegration/SQS". See SpriteLibrary for the available
+	// libraries.
+	Sprite string
+
+	// An optional list of properties describing the queue e.g. an owner or
+	// SLA. These are displayed as a table within the queue.
+	Properties []Property
+
+	// An optional list of tags classifying the queue e.g. "pci". Diagrams can
+	// be filtered by tag using Diagram.Filter.
+	Tags []string
 }
 
 // MustNewQueue is the same as NewQueue, but panics on any error.
@@ -32,10 +45,14 @@ func MustNewQueue(ctx context.Context, id string, args QueueArgs) *Queue {
 // NewQueue constructs a queue container that can be used in a Diagram.
 func NewQueue(ctx context.Context, id string, args QueueArgs) (*Queue, error) {
 	c := &Queue{
-		id:          id,
-		name:        args.Name,
-		description: args.Description,
-		external:    args.External,
+		id:           id,
+		name:         args.Name,
+		description:  args.Description,
+		technologies: args.Technologies,
+		external:     args.External,
+		sprite:       args.Sprite,
+		properties:   args.Properties,
+		tags:         args.Tags,
 	}
 	return c, nil
 }
@@ -50,9 +67,33 @@ type Queue struct {
 	description  string
 	technologies []string
 	external     bool
+	sprite       string
+	tags         []string
+	properties   []Property
 }
 
 // ID satisfies the Element interface.
 func (db *Queue) ID() string {
 	return db.id
 }
+
+// Name returns the human-readable name of the queue.
+func (q *Queue) Name() string { return q.name }
+
+// Description returns the description of the queue.
+func (q *Queue) Description() string { return q.description }
+
+// Technologies returns the technologies describing the queue.
+func (q *Queue) Technologies() []string { return q.technologies }
+
+// External reports whether the queue is styled as an external element.
+func (q *Queue) External() bool { return q.external }
+
+// Sprite returns the sprite displayed on the queue, if any.
+func (q *Queue) Sprite() string { return q.sprite }
+
+// Properties returns the properties describing the queue.
+func (q *Queue) Properties() []Property { return q.properties }
+
+// Tags returns the tags classifying the queue.
+func (db *Queue) Tags() []string { return db.tags }
@@ -33,6 +33,10 @@ type RelationArgs struct {
 	// An optional list of technologies describing the nature of the interaction
 	// between the elements of the relation e.g. "JSON/HTTPS" or "SQL/TCP".
 	Technologies []string
+
+	// An optional list of properties describing the relation e.g. a data
+	// classification. These are displayed as a table alongside the relation.
+	Properties []Property
 }
 
 // RelationOptions are used to modify display characteristics of a relation.
@@ -51,6 +55,7 @@ func newRelation(ctx context.Context, args RelationArgs, opts ...RelationOption)
 		dst:          args.Dst,
 		description:  args.Description,
 		technologies: args.Technologies,
+		properties:   args.Properties,
 	}
 
 	for _, opt := range opts {
@@ -66,4 +71,17 @@ type relation struct {
 	description  string
 	technologies []string
 	direction    Direction
+	properties   []Property
+	tags         []string
+}
+
+// args returns the arguments used to construct the relation.
+func (r *relation) args() RelationArgs {
+	return RelationArgs{
+		Src:          r.src,
+		Dst:          r.dst,
+		Description:  r.description,
+		Technologies: r.technologies,
+		Properties:   r.properties,
+	}
 }
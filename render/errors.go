@@ -0,0 +1,125 @@
+package render
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/haleyrc/c4"
+)
+
+// SyntaxError describes a diagram that PlantUML was unable to render.
+type SyntaxError struct {
+	// The diagram that was rejected.
+	Diagram *c4.Diagram
+
+	// The line of the generated PlantUML on which the problem was found,
+	// starting from 1, or 0 if unknown.
+	Line int
+
+	// The identifier of the element declared on the line, if any. For
+	// relations, this is the identifier of the source and destination joined
+	// by "->".
+	ID string
+
+	// The offending line of PlantUML.
+	Source string
+
+	// The problem reported by PlantUML.
+	Message string
+}
+
+// Error satisfies the error interface.
+func (e *SyntaxError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Diagram.Title())
+	if e.Line > 0 {
+		fmt.Fprintf(&b, ": line %d", e.Line)
+	}
+	if e.ID != "" {
+		fmt.Fprintf(&b, " (%s)", e.ID)
+	}
+	b.WriteString(": ")
+	b.WriteString(e.Message)
+	return b.String()
+}
+
+// SyntaxErrors is a list of diagrams that PlantUML was unable to render.
+type SyntaxErrors []*SyntaxError
+
+// Error satisfies the error interface, reporting one problem per line.
+func (errs SyntaxErrors) Error() string {
+	msgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// parseSyntaxResult parses the output of PlantUML in syntax mode for a single
+// diagram, returning nil if the diagram is valid. Errors are reported as
+// "ERROR", followed by the zero-based line number within the diagram and the
+// error messages, each on their own line.
+func parseSyntaxResult(d *c4.Diagram, result, source []byte) *SyntaxError {
+	s := bufio.NewScanner(bytes.NewReader(result))
+	if !s.Scan() || strings.TrimSpace(s.Text()) != "ERROR" {
+		return nil
+	}
+
+	line := -1
+	if s.Scan() {
+		if n, err := strconv.Atoi(strings.TrimSpace(s.Text())); err == nil {
+			line = n
+		}
+	}
+	var msgs []string
+	for s.Scan() {
+		if msg := strings.TrimSpace(s.Text()); msg != "" {
+			msgs = append(msgs, msg)
+		}
+	}
+
+	return syntaxError(d, source, line, strings.Join(msgs, ": "))
+}
+
+// syntaxError returns an error for a problem reported by PlantUML on the given
+// line of source, counting from zero. A negative line means the line is
+// unknown.
+func syntaxError(d *c4.Diagram, source []byte, line int, msg string) *SyntaxError {
+	e := &SyntaxError{Diagram: d, Message: msg}
+	if e.Message == "" {
+		e.Message = "syntax error"
+	}
+
+	lines := strings.Split(string(source), "\n")
+	if line >= 0 && line < len(lines) {
+		e.Line = line + 1
+		e.Source = strings.TrimSpace(lines[line])
+		e.ID = lineID(e.Source)
+	}
+
+	return e
+}
+
+// macroRE matches the C4-PlantUML macros used to declare elements and
+// relations, capturing the macro and its first two arguments.
+var macroRE = regexp.MustCompile(`^\s*([A-Za-z_]+)\(\s*([A-Za-z0-9_]+)\s*(?:,\s*([A-Za-z0-9_]+))?`)
+
+// lineID returns the identifier of the element or relation declared on a
+// line of PlantUML.
+func lineID(line string) string {
+	m := macroRE.FindStringSubmatch(line)
+	if m == nil {
+		return ""
+	}
+	if strings.HasPrefix(m[1], "Rel") || strings.HasPrefix(m[1], "BiRel") {
+		if m[3] == "" {
+			return ""
+		}
+		return m[2] + "->" + m[3]
+	}
+	return m[2]
+}
@@ -0,0 +1,271 @@
+package render
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/haleyrc/c4"
+)
+
+// Renderer is implemented by each of the ways of rendering a diagram as an
+// image.
+type Renderer interface {
+	Render(ctx context.Context, w io.Writer, d *c4.Diagram) error
+}
+
+var (
+	_ Renderer = (*PlantUML)(nil)
+	_ Renderer = (*Server)(nil)
+	_ Renderer = (*Kroki)(nil)
+)
+
+// DefaultServerURL is the public PlantUML server. Diagrams sent to it are
+// visible to its operators, so private diagrams should be rendered with a
+// server of your own.
+const DefaultServerURL = "https://www.plantuml.com/plantuml"
+
+type ServerArgs struct {
+	// The base URL of the PlantUML server, without the image format e.g.
+	// "http://localhost:8080". Defaults to DefaultServerURL.
+	URL string
+
+	// The format of the rendered images. Defaults to FormatPNG.
+	Format Format
+
+	// The client used to make requests. Defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// Server renders diagrams using a PlantUML server such as the one provided by
+// the plantuml/plantuml-server container image.
+type Server struct {
+	url    string
+	format Format
+	client *http.Client
+}
+
+// NewServer returns a renderer that fetches images from a PlantUML server.
+func NewServer(ctx context.Context, args ServerArgs) (*Server, error) {
+	s := &Server{
+		url:    strings.TrimSuffix(args.URL, "/"),
+		format: args.Format,
+		client: args.Client,
+	}
+	if s.url == "" {
+		s.url = DefaultServerURL
+	}
+	if s.format == "" {
+		s.format = FormatPNG
+	}
+	if _, err := ParseFormat(string(s.format)); err != nil {
+		return nil, err
+	}
+	if s.client == nil {
+		s.client = http.DefaultClient
+	}
+	return s, nil
+}
+
+// MustNewServer is the same as NewServer, but panics on error.
+func MustNewServer(ctx context.Context, args ServerArgs) *Server {
+	s, err := NewServer(ctx, args)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// URL returns the URL of the rendered image for the diagram.
+func (s *Server) URL(ctx context.Context, d *c4.Diagram) (string, error) {
+	return d.EncodedURL(ctx, s.url+"/"+string(s.format))
+}
+
+// Render fetches the image for the diagram and writes it to w. If the server
+// rejects the diagram, the error is a SyntaxErrors value describing the
+// problem.
+func (s *Server) Render(ctx context.Context, w io.Writer, d *c4.Diagram) error {
+	var src bytes.Buffer
+	if err := d.PlantUML(ctx, &src); err != nil {
+		return fmt.Errorf("render: %s: %w", d.Title(), err)
+	}
+	enc, err := c4.EncodePlantUML(src.Bytes())
+	if err != nil {
+		return fmt.Errorf("render: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url+"/"+string(s.format)+"/"+enc, nil)
+	if err != nil {
+		return fmt.Errorf("render: %w", err)
+	}
+
+	// The server reports syntax errors in headers, and the line is counted
+	// from zero in the same way as the command line.
+	return fetch(s.client, req, w, func(resp *http.Response, body []byte) error {
+		msg := resp.Header.Get("X-PlantUML-Diagram-Error")
+		if msg == "" {
+			return nil
+		}
+		line, err := strconv.Atoi(resp.Header.Get("X-PlantUML-Diagram-Error-Line"))
+		if err != nil {
+			line = -1
+		}
+		return SyntaxErrors{syntaxError(d, src.Bytes(), line, msg)}
+	})
+}
+
+// DefaultKrokiURL is the public Kroki service. As with DefaultServerURL,
+// private diagrams should be rendered with an instance of your own.
+const DefaultKrokiURL = "https://kroki.io"
+
+type KrokiArgs struct {
+	// The base URL of the Kroki service e.g. "http://localhost:8000".
+	// Defaults to DefaultKrokiURL.
+	URL string
+
+	// The format of the rendered images. Defaults to FormatPNG.
+	Format Format
+
+	// The client used to make requests. Defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// Kroki renders diagrams using a Kroki service (https://kroki.io).
+type Kroki struct {
+	url    string
+	format Format
+	client *http.Client
+}
+
+// NewKroki returns a renderer that fetches images from a Kroki service.
+func NewKroki(ctx context.Context, args KrokiArgs) (*Kroki, error) {
+	k := &Kroki{
+		url:    strings.TrimSuffix(args.URL, "/"),
+		format: args.Format,
+		client: args.Client,
+	}
+	if k.url == "" {
+		k.url = DefaultKrokiURL
+	}
+	if k.format == "" {
+		k.format = FormatPNG
+	}
+	if _, err := ParseFormat(string(k.format)); err != nil {
+		return nil, err
+	}
+	if k.client == nil {
+		k.client = http.DefaultClient
+	}
+	return k, nil
+}
+
+// MustNewKroki is the same as NewKroki, but panics on error.
+func MustNewKroki(ctx context.Context, args KrokiArgs) *Kroki {
+	k, err := NewKroki(ctx, args)
+	if err != nil {
+		panic(err)
+	}
+	return k
+}
+
+// URL returns the URL of the rendered image for the diagram. Kroki uses its
+// own encoding, compressing the diagram with zlib and encoding the result as
+// URL-safe base64.
+func (k *Kroki) URL(ctx context.Context, d *c4.Diagram) (string, error) {
+	var src bytes.Buffer
+	if err := d.PlantUML(ctx, &src); err != nil {
+		return "", err
+	}
+
+	var buff bytes.Buffer
+	zw, err := zlib.NewWriterLevel(&buff, zlib.BestCompression)
+	if err != nil {
+		return "", err
+	}
+	if _, err := zw.Write(src.Bytes()); err != nil {
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+
+	return k.url + "/plantuml/" + string(k.format) + "/" + base64.URLEncoding.EncodeToString(buff.Bytes()), nil
+}
+
+// Render posts the diagram to Kroki and writes the image to w. If Kroki
+// rejects the diagram, the error is a SyntaxErrors value describing the
+// problem.
+func (k *Kroki) Render(ctx context.Context, w io.Writer, d *c4.Diagram) error {
+	var src bytes.Buffer
+	if err := d.PlantUML(ctx, &src); err != nil {
+		return fmt.Errorf("render: %s: %w", d.Title(), err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, k.url+"/plantuml/"+string(k.format), bytes.NewReader(src.Bytes()))
+	if err != nil {
+		return fmt.Errorf("render: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain")
+
+	return fetch(k.client, req, w, func(resp *http.Response, body []byte) error {
+		if resp.StatusCode != http.StatusBadRequest {
+			return nil
+		}
+		msg, line := parseKrokiError(string(body))
+		return SyntaxErrors{syntaxError(d, src.Bytes(), line, msg)}
+	})
+}
+
+// krokiLineRE matches the line number Kroki appends to PlantUML errors e.g.
+// "Syntax Error? (line: 12)".
+var krokiLineRE = regexp.MustCompile(`\s*\(line: (\d+)\)`)
+
+func parseKrokiError(body string) (string, int) {
+	msg := strings.TrimSpace(body)
+	msg = strings.TrimPrefix(msg, "Error 400: ")
+	line := -1
+	if m := krokiLineRE.FindStringSubmatch(msg); m != nil {
+		line, _ = strconv.Atoi(m[1])
+		msg = strings.TrimSpace(krokiLineRE.ReplaceAllString(msg, ""))
+	}
+	return msg, line
+}
+
+// fetch performs the request and copies a successful response to w. Failed
+// responses are passed to syntax, which returns an error if the response
+// describes a problem with the diagram.
+func fetch(client *http.Client, req *http.Request, w io.Writer, syntax func(*http.Response, []byte) error) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("render: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("render: %s: %w", req.URL.Host, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		if err := syntax(resp, body); err != nil {
+			return err
+		}
+		msg := strings.TrimSpace(string(body))
+		if len(msg) > 200 {
+			msg = msg[:200] + "..."
+		}
+		return fmt.Errorf("render: %s: %s: %s", req.URL.Host, resp.Status, msg)
+	}
+
+	if _, err := w.Write(body); err != nil {
+		return fmt.Errorf("render: write: %w", err)
+	}
+	return nil
+}
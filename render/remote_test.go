@@ -0,0 +1,214 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/haleyrc/c4"
+)
+
+// testDiagram returns a diagram along with its PlantUML source and the
+// zero-based line on which the customer is declared.
+func testDiagram(t *testing.T) (*c4.Diagram, string, int) {
+	t.Helper()
+	ctx := context.Background()
+	d, err := c4.NewDiagram(ctx, "Remote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	customer := c4.MustNewPerson(ctx, "customer", c4.PersonArgs{Name: "Customer"})
+	system := c4.MustNewSystem(ctx, "bank", c4.SystemArgs{Name: "Bank"})
+	d.AddElement(ctx, customer)
+	d.AddElement(ctx, system)
+	if err := d.NewRelation(ctx, c4.RelationArgs{Src: customer, Dst: system, Description: "Uses"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var src bytes.Buffer
+	if err := d.PlantUML(ctx, &src); err != nil {
+		t.Fatal(err)
+	}
+	for i, line := range strings.Split(src.String(), "\n") {
+		if strings.HasPrefix(line, "Person(customer,") {
+			return d, src.String(), i
+		}
+	}
+	t.Fatalf("customer not found in:\n%s", src.String())
+	return nil, "", 0
+}
+
+// syntaxErr returns the only SyntaxError in err.
+func syntaxErr(t *testing.T, err error) *SyntaxError {
+	t.Helper()
+	var errs SyntaxErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("got error %v, want SyntaxErrors", err)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("got %d syntax errors, want 1", len(errs))
+	}
+	return errs[0]
+}
+
+func TestServerRender(t *testing.T) {
+	ctx := context.Background()
+	d, src, _ := testDiagram(t)
+	enc, err := c4.EncodePlantUML([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("got method %s, want GET", r.Method)
+		}
+		if want := "/svg/" + enc; r.URL.Path != want {
+			t.Errorf("got path %q, want %q", r.URL.Path, want)
+		}
+		io.WriteString(w, "<svg/>")
+	}))
+	defer ts.Close()
+
+	s := MustNewServer(ctx, ServerArgs{URL: ts.URL + "/", Format: FormatSVG, Client: ts.Client()})
+	var out bytes.Buffer
+	if err := s.Render(ctx, &out, d); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if got := out.String(); got != "<svg/>" {
+		t.Errorf("Render wrote %q, want %q", got, "<svg/>")
+	}
+}
+
+func TestServerRenderSyntaxError(t *testing.T) {
+	ctx := context.Background()
+	d, _, line := testDiagram(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-PlantUML-Diagram-Error", "Syntax Error?")
+		w.Header().Set("X-PlantUML-Diagram-Error-Line", fmt.Sprint(line))
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, "<svg>error</svg>")
+	}))
+	defer ts.Close()
+
+	s := MustNewServer(ctx, ServerArgs{URL: ts.URL, Format: FormatSVG, Client: ts.Client()})
+	var out bytes.Buffer
+	e := syntaxErr(t, s.Render(ctx, &out, d))
+	if e.Diagram != d {
+		t.Errorf("got diagram %v, want %v", e.Diagram, d)
+	}
+	if e.Line != line+1 {
+		t.Errorf("got line %d, want %d", e.Line, line+1)
+	}
+	if e.ID != "customer" {
+		t.Errorf("got ID %q, want %q", e.ID, "customer")
+	}
+	if e.Message != "Syntax Error?" {
+		t.Errorf("got message %q, want %q", e.Message, "Syntax Error?")
+	}
+	if out.Len() > 0 {
+		t.Errorf("Render wrote %q after an error", out.String())
+	}
+}
+
+func TestServerRenderError(t *testing.T) {
+	ctx := context.Background()
+	d, _, _ := testDiagram(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	s := MustNewServer(ctx, ServerArgs{URL: ts.URL, Client: ts.Client()})
+	err := s.Render(ctx, io.Discard, d)
+	if err == nil {
+		t.Fatal("Render succeeded, want an error")
+	}
+	var errs SyntaxErrors
+	if errors.As(err, &errs) {
+		t.Errorf("got syntax errors %v for an unavailable server", errs)
+	}
+	if !strings.Contains(err.Error(), "503") {
+		t.Errorf("got error %q, want the status", err)
+	}
+}
+
+func TestKrokiRender(t *testing.T) {
+	ctx := context.Background()
+	d, src, _ := testDiagram(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("got method %s, want POST", r.Method)
+		}
+		if r.URL.Path != "/plantuml/png" {
+			t.Errorf("got path %q, want %q", r.URL.Path, "/plantuml/png")
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		if string(body) != src {
+			t.Errorf("got body %q, want %q", body, src)
+		}
+		io.WriteString(w, "PNG")
+	}))
+	defer ts.Close()
+
+	k := MustNewKroki(ctx, KrokiArgs{URL: ts.URL, Client: ts.Client()})
+	var out bytes.Buffer
+	if err := k.Render(ctx, &out, d); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if got := out.String(); got != "PNG" {
+		t.Errorf("Render wrote %q, want %q", got, "PNG")
+	}
+}
+
+func TestKrokiRenderSyntaxError(t *testing.T) {
+	ctx := context.Background()
+	d, _, line := testDiagram(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, fmt.Sprintf("Error 400: Syntax Error? (line: %d)", line), http.StatusBadRequest)
+	}))
+	defer ts.Close()
+
+	k := MustNewKroki(ctx, KrokiArgs{URL: ts.URL, Client: ts.Client()})
+	e := syntaxErr(t, k.Render(ctx, io.Discard, d))
+	if e.Line != line+1 {
+		t.Errorf("got line %d, want %d", e.Line, line+1)
+	}
+	if e.ID != "customer" {
+		t.Errorf("got ID %q, want %q", e.ID, "customer")
+	}
+	if e.Message != "Syntax Error?" {
+		t.Errorf("got message %q, want %q", e.Message, "Syntax Error?")
+	}
+}
+
+func TestParseKrokiError(t *testing.T) {
+	tests := []struct {
+		body string
+		msg  string
+		line int
+	}{
+		{"Error 400: Syntax Error? (line: 12)\n", "Syntax Error?", 12},
+		{"Syntax Error?", "Syntax Error?", -1},
+		{"Error 400: Unknown macro (line: 3) near Foo", "Unknown macro near Foo", 3},
+	}
+	for _, tt := range tests {
+		msg, line := parseKrokiError(tt.body)
+		if msg != tt.msg || line != tt.line {
+			t.Errorf("parseKrokiError(%q) = %q, %d, want %q, %d", tt.body, msg, line, tt.msg, tt.line)
+		}
+	}
+}
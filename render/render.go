@@ -0,0 +1,378 @@
+// Package render converts diagrams into images using either a local
+// installation of PlantUML, a PlantUML server or a Kroki service.
+//
+// With PlantUML, diagrams are written to a subprocess reading from standard
+// input, so no temporary files are created. Many diagrams can be
+// rendered with a single process using RenderAll, which avoids paying the
+// start-up cost of the JVM for each one.
+//
+//	r, err := render.NewPlantUML(ctx, render.PlantUMLArgs{
+//		Jar:    "./plantuml/plantuml.jar",
+//		Format: render.FormatSVG,
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	if err := r.Render(ctx, f, d); err != nil {
+//		return err
+//	}
+//
+// The context passed to each method bounds the lifetime of the subprocess, so
+// a deadline can be used to guard against diagrams that PlantUML struggles to
+// lay out.
+//
+// Server and Kroki render diagrams over HTTP, which avoids the need for Java
+// where a PlantUML server or Kroki instance is already available. Each
+// renderer implements Renderer, and reports diagrams that PlantUML rejects as
+// SyntaxErrors.
+package render
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/haleyrc/c4"
+)
+
+// Format is an image format supported by PlantUML.
+type Format string
+
+const (
+	FormatPNG Format = "png"
+	FormatSVG Format = "svg"
+	FormatPDF Format = "pdf"
+)
+
+// Ext returns the file extension conventionally used for the format, including
+// the leading dot.
+func (f Format) Ext() string { return "." + string(f) }
+
+var formats = []Format{FormatPNG, FormatSVG, FormatPDF}
+
+// ParseFormat returns the format with the given name e.g. "svg".
+func ParseFormat(name string) (Format, error) {
+	for _, f := range formats {
+		if string(f) == strings.ToLower(name) {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("render: unknown format %q", name)
+}
+
+const (
+	// DefaultJava is the java executable used to run PlantUMLArgs.Jar.
+	DefaultJava = "java"
+
+	// DefaultCommand is the PlantUML executable used when no jar is
+	// provided, as installed by most package managers.
+	DefaultCommand = "plantuml"
+)
+
+// waitDelay is how long to wait for PlantUML to exit after the context is
+// done before giving up on it.
+const waitDelay = 5 * time.Second
+
+type PlantUMLArgs struct {
+	// The path to plantuml.jar. If empty, Command is run instead.
+	Jar string
+
+	// The java executable used to run Jar. Defaults to DefaultJava.
+	Java string
+
+	// The PlantUML executable used when Jar is empty. Defaults to
+	// DefaultCommand.
+	Command string
+
+	// The format of the rendered images. Defaults to FormatPNG.
+	Format Format
+
+	// Additional arguments passed to PlantUML e.g.
+	// "-DPLANTUML_LIMIT_SIZE=8192".
+	Args []string
+}
+
+// PlantUML renders diagrams by running PlantUML as a subprocess.
+type PlantUML struct {
+	name   string
+	args   []string
+	format Format
+}
+
+// NewPlantUML returns a renderer that runs the configured PlantUML.
+func NewPlantUML(ctx context.Context, args PlantUMLArgs) (*PlantUML, error) {
+	p := &PlantUML{format: args.Format}
+	if p.format == "" {
+		p.format = FormatPNG
+	}
+	if _, err := ParseFormat(string(p.format)); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case args.Jar != "":
+		p.name = args.Java
+		if p.name == "" {
+			p.name = DefaultJava
+		}
+		p.args = []string{"-Djava.awt.headless=true", "-jar", args.Jar}
+	default:
+		p.name = args.Command
+		if p.name == "" {
+			p.name = DefaultCommand
+		}
+	}
+	p.args = append(p.args, args.Args...)
+
+	return p, nil
+}
+
+// MustNewPlantUML is the same as NewPlantUML, but panics on error.
+func MustNewPlantUML(ctx context.Context, args PlantUMLArgs) *PlantUML {
+	p, err := NewPlantUML(ctx, args)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Format returns the format of the images produced by the renderer.
+func (p *PlantUML) Format() Format { return p.format }
+
+// Render renders the diagram, writing the image to w. If PlantUML rejects the
+// diagram, the error is a SyntaxErrors value describing the problem.
+func (p *PlantUML) Render(ctx context.Context, w io.Writer, d *c4.Diagram) error {
+	images, err := p.RenderAll(ctx, d)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(images[0]); err != nil {
+		return fmt.Errorf("render: write: %w", err)
+	}
+	return nil
+}
+
+// RenderAll renders each of the diagrams using a single PlantUML process and
+// returns the images in the same order. If PlantUML rejects any of the
+// diagrams, no images are returned and the error is a SyntaxErrors value
+// describing the problem with each one.
+func (p *PlantUML) RenderAll(ctx context.Context, ds ...*c4.Diagram) ([][]byte, error) {
+	if len(ds) == 0 {
+		return nil, nil
+	}
+
+	sources := make([][]byte, len(ds))
+	for i, d := range ds {
+		var buff bytes.Buffer
+		if err := d.PlantUML(ctx, &buff); err != nil {
+			return nil, fmt.Errorf("render: %s: %w", d.Title(), err)
+		}
+		sources[i] = buff.Bytes()
+	}
+
+	delim, err := delimiter()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := p.run(ctx, delim, sources, "-t"+string(p.format))
+	if err != nil {
+		return nil, err
+	}
+
+	if res.err != nil || hasErrors(res.stderr) {
+		// PlantUML reports problems on standard error without saying which
+		// diagram they belong to, so the diagrams are checked again in
+		// syntax mode where the results are written in order.
+		errs, err := p.check(ctx, delim, ds, sources)
+		if err != nil {
+			return nil, err
+		}
+		if len(errs) > 0 {
+			return nil, errs
+		}
+		if res.err != nil {
+			return nil, res.processError()
+		}
+	}
+
+	images := split(res.stdout, delim)
+	if len(images) != len(ds) {
+		return nil, fmt.Errorf("render: expected %d images from plantuml, got %d", len(ds), len(images))
+	}
+	return images, nil
+}
+
+// check runs PlantUML in syntax mode, returning an error for each diagram that
+// it rejects.
+func (p *PlantUML) check(ctx context.Context, delim string, ds []*c4.Diagram, sources [][]byte) (SyntaxErrors, error) {
+	res, err := p.run(ctx, delim, sources, "-syntax")
+	if err != nil {
+		return nil, err
+	}
+
+	results := split(res.stdout, delim)
+	if len(results) != len(ds) {
+		if res.err != nil {
+			return nil, res.processError()
+		}
+		return nil, fmt.Errorf("render: expected %d results from plantuml, got %d", len(ds), len(results))
+	}
+
+	var errs SyntaxErrors
+	for i, result := range results {
+		if e := parseSyntaxResult(ds[i], result, sources[i]); e != nil {
+			errs = append(errs, e)
+		}
+	}
+	return errs, nil
+}
+
+// result is the output of a PlantUML process that ran to completion.
+type result struct {
+	stdout []byte
+	stderr []byte
+
+	// The error describing a non-zero exit status, if any.
+	err error
+}
+
+func (r *result) processError() error {
+	if msg := strings.TrimSpace(string(r.stderr)); msg != "" {
+		return fmt.Errorf("render: plantuml: %w: %s", r.err, msg)
+	}
+	return fmt.Errorf("render: plantuml: %w", r.err)
+}
+
+// run pipes the sources through PlantUML. An error is only returned if
+// PlantUML can't be started or the context is done before it exits.
+func (p *PlantUML) run(ctx context.Context, delim string, sources [][]byte, args ...string) (*result, error) {
+	args = append(append([]string{}, p.args...), args...)
+	args = append(args, "-charset", "UTF-8", "-pipe", "-pipedelimitor", delim)
+
+	cmd := exec.CommandContext(ctx, p.name, args...)
+	cmd.WaitDelay = waitDelay
+	cmd.Stdin = bytes.NewReader(bytes.Join(sources, []byte("\n")))
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("render: %w", ctx.Err())
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return nil, fmt.Errorf("render: plantuml: %w", err)
+	}
+
+	return &result{stdout: stdout.Bytes(), stderr: stderr.Bytes(), err: err}, nil
+}
+
+// delimiter returns a random string used to separate the output of each
+// diagram. A random value prevents a delimiter from appearing in a binary
+// image by chance.
+func delimiter() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("render: %w", err)
+	}
+	return "--c4-" + hex.EncodeToString(b) + "--", nil
+}
+
+// split separates the output for each diagram. PlantUML writes the delimiter
+// on its own line after each diagram.
+func split(out []byte, delim string) [][]byte {
+	parts := bytes.Split(out, []byte(delim))
+	parts = parts[:len(parts)-1]
+	for i := 1; i < len(parts); i++ {
+		parts[i] = bytes.TrimPrefix(parts[i], []byte("\r"))
+		parts[i] = bytes.TrimPrefix(parts[i], []byte("\n"))
+	}
+	return parts
+}
+
+func hasErrors(stderr []byte) bool {
+	for _, line := range strings.Split(string(stderr), "\n") {
+		if strings.TrimSpace(line) == "ERROR" {
+			return true
+		}
+	}
+	return false
+}
+
+// batchRenderer is implemented by renderers that can render many diagrams at
+// once.
+type batchRenderer interface {
+	RenderAll(ctx context.Context, ds ...*c4.Diagram) ([][]byte, error)
+}
+
+// All renders each of the diagrams using r and returns the images in the same
+// order. Renderers that can render many diagrams at once, such as PlantUML, are
+// used to do so. If any of the diagrams are rejected, the error is a
+// SyntaxErrors value describing each of them, while the images of the other
+// diagrams are still returned.
+func All(ctx context.Context, r Renderer, ds ...*c4.Diagram) ([][]byte, error) {
+	br, ok := r.(batchRenderer)
+	if !ok {
+		var errs SyntaxErrors
+		images := make([][]byte, len(ds))
+		for i, d := range ds {
+			var buff bytes.Buffer
+			err := r.Render(ctx, &buff, d)
+			var syntaxErrs SyntaxErrors
+			if errors.As(err, &syntaxErrs) {
+				errs = append(errs, syntaxErrs...)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			images[i] = buff.Bytes()
+		}
+		if len(errs) > 0 {
+			return images, errs
+		}
+		return images, nil
+	}
+
+	images, err := br.RenderAll(ctx, ds...)
+	var errs SyntaxErrors
+	if !errors.As(err, &errs) {
+		return images, err
+	}
+
+	// A rejected diagram prevents the whole batch from rendering, so the
+	// remaining diagrams are rendered again without it.
+	rejected := map[*c4.Diagram]bool{}
+	for _, e := range errs {
+		rejected[e.Diagram] = true
+	}
+	var (
+		valid   []*c4.Diagram
+		indices []int
+	)
+	for i, d := range ds {
+		if !rejected[d] {
+			valid = append(valid, d)
+			indices = append(indices, i)
+		}
+	}
+	validImages, err := br.RenderAll(ctx, valid...)
+	if err != nil {
+		return nil, err
+	}
+	images = make([][]byte, len(ds))
+	for i, image := range validImages {
+		images[indices[i]] = image
+	}
+	return images, errs
+}
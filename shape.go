@@ -0,0 +1,30 @@
+package c4
+
+// Shape represents an alternate shape for elements that can be drawn as
+// something other than a plain box. This allows systems and components to be
+// displayed as databases or queues in the same way that the Database and Queue
+// types do for containers.
+type Shape string
+
+const (
+	// ShapeDefault displays the element using the standard shape for its type.
+	ShapeDefault Shape = ""
+
+	// ShapeDatabase displays the element as a database cylinder.
+	ShapeDatabase Shape = "Db"
+
+	// ShapeQueue displays the element as a queue.
+	ShapeQueue Shape = "Queue"
+)
+
+// describe qualifies kind with the shape, if any, in the same way the Database
+// and Queue types are described e.g. "Component (Database)".
+func (s Shape) describe(kind string) string {
+	switch s {
+	case ShapeDatabase:
+		return kind + " (Database)"
+	case ShapeQueue:
+		return kind + " (Queue)"
+	}
+	return kind
+}
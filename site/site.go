@@ -0,0 +1,361 @@
+// Package site generates a static HTML site documenting an architecture.
+//
+// The site is built from a set of diagrams, such as the views of a model, and
+// contains a page for each diagram along with a page for every system,
+// container and component that appears in them. Element pages show the
+// element's description, technologies and properties, its incoming and
+// outgoing relations, and each diagram it appears in, with links between
+// related pages. The resulting directory can be served by any static file
+// server, or uploaded to a storage bucket.
+//
+//	r, _ := render.NewPlantUML(ctx, render.PlantUMLArgs{Format: render.FormatSVG})
+//	err := site.Generate(ctx, "./site", site.GenerateArgs{
+//		Title:    "Big Bank plc",
+//		Diagrams: []*c4.Diagram{contextDiagram, containerDiagram},
+//		Renderer: r,
+//	})
+package site
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/haleyrc/c4"
+	"github.com/haleyrc/c4/internal/alias"
+	"github.com/haleyrc/c4/render"
+)
+
+type GenerateArgs struct {
+	// The title of the site.
+	Title string
+
+	// The diagrams to include, in the order they should be listed.
+	Diagrams []*c4.Diagram
+
+	// The renderer used to produce an image for each diagram. The renderer
+	// must produce SVG images.
+	Renderer render.Renderer
+}
+
+// Generate writes the site to dir, creating it if necessary. Existing files
+// with the same names are overwritten, but other files are left alone.
+func Generate(ctx context.Context, dir string, args GenerateArgs) error {
+	if args.Renderer == nil {
+		return fmt.Errorf("site: a renderer is required")
+	}
+
+	s := build(args.Title, args.Diagrams)
+
+	images, err := render.All(ctx, args.Renderer, args.Diagrams...)
+	if err != nil {
+		return fmt.Errorf("site: %w", err)
+	}
+
+	files := map[string][]byte{"style.css": []byte(stylesheet)}
+	for i, dp := range s.diagrams {
+		files[filepath.Join("diagrams", dp.Slug+".svg")] = images[i]
+	}
+
+	write := func(name, tmpl string, data pageData) error {
+		var buff bytes.Buffer
+		if err := templates.ExecuteTemplate(&buff, tmpl, data); err != nil {
+			return fmt.Errorf("site: %s: %w", name, err)
+		}
+		files[name] = buff.Bytes()
+		return nil
+	}
+	if err := write("index.html", "index", pageData{Site: s, Root: ""}); err != nil {
+		return err
+	}
+	for _, dp := range s.diagrams {
+		if err := write(filepath.Join("diagrams", dp.Slug+".html"), "diagram", pageData{Site: s, Root: "../", Diagram: dp}); err != nil {
+			return err
+		}
+	}
+	for _, ep := range s.elements {
+		if err := write(filepath.Join("elements", ep.file()), "element", pageData{Site: s, Root: "../", Element: ep}); err != nil {
+			return err
+		}
+	}
+
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("site: %w", err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return fmt.Errorf("site: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// kinds lists the kinds of element that have their own page, along with the
+// label used to describe them. Boundaries are documented by the page for the
+// element they are created from.
+var kinds = map[c4.Kind]string{
+	c4.KindSystem:            "Software System",
+	c4.KindSystemBoundary:    "Software System",
+	c4.KindContainer:         "Container",
+	c4.KindContainerBoundary: "Container",
+	c4.KindDatabase:          "Database",
+	c4.KindDatabaseBoundary:  "Database",
+	c4.KindQueue:             "Queue",
+	c4.KindComponent:         "Component",
+}
+
+type site struct {
+	Title    string
+	diagrams []*diagramPage
+	elements []*elementPage
+	byID     map[string]*elementPage
+}
+
+// Systems returns the pages for systems that aren't nested in another
+// element, which form the entry points of the site.
+func (s *site) Systems() []*elementPage {
+	var pages []*elementPage
+	for _, ep := range s.elements {
+		if ep.Parent == nil && ep.Kind == kinds[c4.KindSystem] {
+			pages = append(pages, ep)
+		}
+	}
+	return pages
+}
+
+// Elements returns the pages for every element, sorted by name.
+func (s *site) Elements() []*elementPage {
+	pages := append([]*elementPage{}, s.elements...)
+	sort.SliceStable(pages, func(i, j int) bool {
+		return strings.ToLower(pages[i].Name) < strings.ToLower(pages[j].Name)
+	})
+	return pages
+}
+
+// Diagrams returns the pages for each diagram.
+func (s *site) Diagrams() []*diagramPage { return s.diagrams }
+
+type diagramPage struct {
+	Title    string
+	Slug     string
+	Elements []*elementPage
+}
+
+type elementPage struct {
+	ID           string
+	Name         string
+	Kind         string
+	Description  string
+	Technologies []string
+	External     bool
+	Properties   []c4.Property
+	Parent       *elementPage
+	Children     []*elementPage
+	Incoming     []*relation
+	Outgoing     []*relation
+	Diagrams     []*diagramPage
+}
+
+func (ep *elementPage) file() string { return alias.Make(ep.ID) + ".html" }
+
+// relation is a relation between two elements. Elements without a page of
+// their own, such as people, are displayed by name only.
+type relation struct {
+	Src          endpoint
+	Dst          endpoint
+	Description  string
+	Technologies []string
+}
+
+type endpoint struct {
+	Name string
+	Page *elementPage
+}
+
+type pageData struct {
+	Site    *site
+	Root    string
+	Diagram *diagramPage
+	Element *elementPage
+}
+
+// build collects the elements and relations of the diagrams. Elements that
+// appear in several diagrams are identified by their identifier.
+func build(title string, ds []*c4.Diagram) *site {
+	s := &site{Title: title, byID: map[string]*elementPage{}}
+
+	slugs := map[string]int{}
+	for _, d := range ds {
+		dp := &diagramPage{Title: d.Title(), Slug: slug(d.Title())}
+		if n := slugs[dp.Slug]; n > 0 {
+			dp.Slug = fmt.Sprintf("%s-%d", dp.Slug, n+1)
+		}
+		slugs[slug(d.Title())]++
+		s.diagrams = append(s.diagrams, dp)
+
+		s.collect(dp, nil, d.Elements())
+	}
+
+	seen := map[string]bool{}
+	for _, d := range ds {
+		for _, rel := range d.Relations() {
+			r := &relation{
+				Src:          s.endpoint(rel.Src),
+				Dst:          s.endpoint(rel.Dst),
+				Description:  rel.Description,
+				Technologies: rel.Technologies,
+			}
+			key := strings.Join([]string{resolve(rel.Src).ID(), resolve(rel.Dst).ID(), rel.Description}, "\x00")
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if r.Src.Page != nil {
+				r.Src.Page.Outgoing = append(r.Src.Page.Outgoing, r)
+			}
+			if r.Dst.Page != nil {
+				r.Dst.Page.Incoming = append(r.Dst.Page.Incoming, r)
+			}
+		}
+	}
+
+	return s
+}
+
+// collect records the elements of a diagram, nested within parent.
+func (s *site) collect(dp *diagramPage, parent *elementPage, els []c4.Element) {
+	for _, el := range els {
+		el = resolve(el)
+
+		ep := s.page(el)
+		if ep != nil {
+			if !containsPage(dp.Elements, ep) {
+				dp.Elements = append(dp.Elements, ep)
+			}
+			if !containsDiagram(ep.Diagrams, dp) {
+				ep.Diagrams = append(ep.Diagrams, dp)
+			}
+			if parent != nil && ep.Parent == nil && parent != ep {
+				ep.Parent = parent
+				parent.Children = append(parent.Children, ep)
+			}
+		}
+
+		// Children of deployment nodes and enterprise boundaries aren't
+		// part of those elements in the same way that containers are part
+		// of a system, so they keep the current parent.
+		next := parent
+		if ep != nil {
+			next = ep
+		}
+		switch v := el.(type) {
+		case *c4.DeploymentNode:
+			s.collect(dp, parent, v.Elements())
+		case *c4.EnterpriseBoundary:
+			s.collect(dp, parent, v.Elements())
+		case interface{ Elements() []c4.Element }:
+			s.collect(dp, next, v.Elements())
+		}
+	}
+}
+
+// page returns the page for el, creating it if necessary. Elements without
+// pages return nil.
+func (s *site) page(el c4.Element) *elementPage {
+	kind, ok := kinds[c4.KindOf(el)]
+	if !ok {
+		return nil
+	}
+	if ep, ok := s.byID[el.ID()]; ok {
+		return ep
+	}
+
+	ep := &elementPage{ID: el.ID(), Kind: kind}
+	if v, ok := el.(interface{ Name() string }); ok {
+		ep.Name = v.Name()
+	}
+	if v, ok := el.(interface{ Description() string }); ok {
+		ep.Description = v.Description()
+	}
+	if v, ok := el.(interface{ Technologies() []string }); ok {
+		ep.Technologies = v.Technologies()
+	}
+	if v, ok := el.(interface{ External() bool }); ok {
+		ep.External = v.External()
+	}
+	if v, ok := el.(interface{ Properties() []c4.Property }); ok {
+		ep.Properties = v.Properties()
+	}
+
+	s.byID[ep.ID] = ep
+	s.elements = append(s.elements, ep)
+	return ep
+}
+
+func (s *site) endpoint(el c4.Element) endpoint {
+	el = resolve(el)
+	if ep := s.page(el); ep != nil {
+		return endpoint{Name: ep.Name, Page: ep}
+	}
+	if v, ok := el.(interface{ Name() string }); ok {
+		return endpoint{Name: v.Name()}
+	}
+	return endpoint{Name: el.ID()}
+}
+
+// resolve returns the element an instance is an instance of, so that it is
+// documented along with the element itself.
+func resolve(el c4.Element) c4.Element {
+	if inst, ok := el.(*c4.Instance); ok {
+		return inst.Of()
+	}
+	return el
+}
+
+// slug returns a file name for s containing only lowercase letters, digits and
+// hyphens.
+func slug(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range s {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			dash = true
+		}
+	}
+	if b.Len() == 0 {
+		return "untitled"
+	}
+	return b.String()
+}
+
+func containsPage(pages []*elementPage, ep *elementPage) bool {
+	for _, p := range pages {
+		if p == ep {
+			return true
+		}
+	}
+	return false
+}
+
+func containsDiagram(pages []*diagramPage, dp *diagramPage) bool {
+	for _, p := range pages {
+		if p == dp {
+			return true
+		}
+	}
+	return false
+}
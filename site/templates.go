@@ -0,0 +1,156 @@
+package site
+
+import (
+	"html/template"
+	"strings"
+)
+
+type headerData struct {
+	Title string
+	Root  string
+	Site  *site
+}
+
+type linkData struct {
+	Root    string
+	Element *elementPage
+}
+
+type endpointData struct {
+	Root     string
+	Endpoint endpoint
+}
+
+var templates = template.Must(template.New("site").Funcs(template.FuncMap{
+	"join": strings.Join,
+	"elementURL": func(root string, ep *elementPage) string {
+		return root + "elements/" + ep.file()
+	},
+	"diagramURL": func(root string, dp *diagramPage) string {
+		return root + "diagrams/" + dp.Slug + ".html"
+	},
+	"imageURL": func(root string, dp *diagramPage) string {
+		return root + "diagrams/" + dp.Slug + ".svg"
+	},
+	"page": func(title string, data pageData) headerData {
+		return headerData{Title: title, Root: data.Root, Site: data.Site}
+	},
+	"link": func(root string, ep *elementPage) linkData {
+		return linkData{Root: root, Element: ep}
+	},
+	"endpoint": func(root string, e endpoint) endpointData {
+		return endpointData{Root: root, Endpoint: e}
+	},
+}).Parse(`
+{{define "header"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<link rel="stylesheet" href="{{.Root}}style.css">
+</head>
+<body>
+<header><a href="{{.Root}}index.html">{{.Site.Title}}</a></header>
+<main>
+{{end}}
+
+{{define "footer"}}</main>
+</body>
+</html>
+{{end}}
+
+{{define "elementLink"}}<a href="{{elementURL .Root .Element}}">{{.Element.Name}}</a>{{end}}
+
+{{define "endpoint"}}{{if .Endpoint.Page}}<a href="{{elementURL .Root .Endpoint.Page}}">{{.Endpoint.Name}}</a>{{else}}{{.Endpoint.Name}}{{end}}{{end}}
+
+{{define "index"}}{{template "header" (page .Site.Title .)}}
+<h1>{{.Site.Title}}</h1>
+{{with .Site.Systems}}<h2>Systems</h2>
+<ul>
+{{range .}}<li>{{template "elementLink" (link $.Root .)}}{{with .Description}} - {{.}}{{end}}</li>
+{{end}}</ul>
+{{end}}
+{{with .Site.Diagrams}}<h2>Diagrams</h2>
+<ul>
+{{range .}}<li><a href="{{diagramURL $.Root .}}">{{.Title}}</a></li>
+{{end}}</ul>
+{{end}}
+{{with .Site.Elements}}<h2>Elements</h2>
+<table>
+<thead><tr><th>Name</th><th>Type</th><th>Technologies</th></tr></thead>
+<tbody>
+{{range .}}<tr><td>{{template "elementLink" (link $.Root .)}}</td><td>{{.Kind}}</td><td>{{join .Technologies ", "}}</td></tr>
+{{end}}</tbody>
+</table>
+{{end}}
+{{template "footer" .}}{{end}}
+
+{{define "diagram"}}{{template "header" (page .Diagram.Title .)}}
+<h1>{{.Diagram.Title}}</h1>
+<figure><img src="{{imageURL .Root .Diagram}}" alt="{{.Diagram.Title}}"></figure>
+{{with .Diagram.Elements}}<h2>Elements</h2>
+<ul>
+{{range .}}<li>{{template "elementLink" (link $.Root .)}} <span class="kind">[{{.Kind}}]</span></li>
+{{end}}</ul>
+{{end}}
+{{template "footer" .}}{{end}}
+
+{{define "element"}}{{template "header" (page .Element.Name .)}}
+{{with .Element}}
+{{with .Parent}}<p class="parent">Part of {{template "elementLink" (link $.Root .)}}</p>{{end}}
+<h1>{{.Name}}{{if .External}} <span class="external">External</span>{{end}}</h1>
+<p class="kind">[{{.Kind}}{{with .Technologies}}: {{join . ", "}}{{end}}]</p>
+{{with .Description}}<p>{{.}}</p>{{end}}
+{{with .Properties}}<h2>Properties</h2>
+<table>
+<tbody>
+{{range .}}<tr><th>{{.Name}}</th><td>{{.Value}}</td></tr>
+{{end}}</tbody>
+</table>
+{{end}}
+{{with .Children}}<h2>Contains</h2>
+<ul>
+{{range .}}<li>{{template "elementLink" (link $.Root .)}} <span class="kind">[{{.Kind}}]</span>{{with .Description}} - {{.}}{{end}}</li>
+{{end}}</ul>
+{{end}}
+{{with .Outgoing}}<h2>Outgoing relations</h2>
+<table>
+<thead><tr><th>Description</th><th>Destination</th><th>Technologies</th></tr></thead>
+<tbody>
+{{range .}}<tr><td>{{.Description}}</td><td>{{template "endpoint" (endpoint $.Root .Dst)}}</td><td>{{join .Technologies ", "}}</td></tr>
+{{end}}</tbody>
+</table>
+{{end}}
+{{with .Incoming}}<h2>Incoming relations</h2>
+<table>
+<thead><tr><th>Source</th><th>Description</th><th>Technologies</th></tr></thead>
+<tbody>
+{{range .}}<tr><td>{{template "endpoint" (endpoint $.Root .Src)}}</td><td>{{.Description}}</td><td>{{join .Technologies ", "}}</td></tr>
+{{end}}</tbody>
+</table>
+{{end}}
+{{with .Diagrams}}<h2>Diagrams</h2>
+{{range .}}<figure>
+<figcaption><a href="{{diagramURL $.Root .}}">{{.Title}}</a></figcaption>
+<img src="{{imageURL $.Root .}}" alt="{{.Title}}">
+</figure>
+{{end}}{{end}}
+{{end}}
+{{template "footer" .}}{{end}}
+`))
+
+const stylesheet = `body { margin: 0; font-family: Helvetica, Arial, sans-serif; color: #262626; line-height: 1.5; }
+header { background: #4E668A; padding: 0.75rem 2rem; }
+header a { color: #F5F5F5; font-weight: bold; text-decoration: none; }
+main { max-width: 72rem; margin: 0 auto; padding: 1rem 2rem; }
+a { color: #2F5597; }
+table { border-collapse: collapse; margin: 1rem 0; }
+th, td { border: 1px solid #D0D7E2; padding: 0.35rem 0.75rem; text-align: left; vertical-align: top; }
+thead th, tbody th { background: #F2F5FA; }
+figure { margin: 1.5rem 0; }
+figcaption { font-weight: bold; margin-bottom: 0.5rem; }
+img { max-width: 100%; }
+.kind, .parent { color: #666666; }
+.external { font-size: 0.6em; vertical-align: middle; background: #999999; color: #FFFFFF; padding: 0.1rem 0.4rem; border-radius: 0.25rem; }
+`
@@ -0,0 +1,168 @@
+package c4
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SpriteLibrary identifies a set of icons from the PlantUML standard library
+// that can be displayed on elements. Sprites from a library are referenced by
+// prefixing the path of the icon within the library with the library name e.g.
+// "aws/Compute/EC2" or "devicons/postgresql".
+//
+// In order for PlantUML to find the icons, the library must be enabled on the
+// diagram using the WithSprites option, and diagrams using sprites from a
+// library that isn't enabled fail to validate. The necessary include lines are
+// only added for the sprites that are actually used by elements in the diagram.
+type SpriteLibrary string
+
+const (
+	// Amazon Web Services icons e.g. "aws/Compute/EC2".
+	SpritesAWS SpriteLibrary = "aws"
+
+	// Microsoft Azure icons e.g. "azure/Compute/AzureVirtualMachine".
+	SpritesAzure SpriteLibrary = "azure"
+
+	// Google Cloud Platform icons e.g. "gcp/Compute/Compute_Engine".
+	SpritesGCP SpriteLibrary = "gcp"
+
+	// Kubernetes resource icons e.g. "k8s/pod" or "k8s/svc".
+	SpritesKubernetes SpriteLibrary = "k8s"
+
+	// Technology logos from devicons e.g. "devicons/postgresql".
+	SpritesDevicons SpriteLibrary = "devicons"
+
+	// General purpose icons from Font Awesome e.g. "font-awesome/server".
+	SpritesFontAwesome SpriteLibrary = "font-awesome"
+)
+
+type spriteLibrary struct {
+	// Files that must be included before any individual sprite.
+	common []string
+
+	// The standard library directory holding one file per sprite. If empty,
+	// the common files define every sprite in the library.
+	dir string
+}
+
+var spriteLibraries = map[SpriteLibrary]spriteLibrary{
+	SpritesAWS: {
+		common: []string{"awslib/AWSCommon"},
+		dir:    "awslib",
+	},
+	SpritesAzure: {
+		common: []string{"azure/AzureCommon"},
+		dir:    "azure",
+	},
+	SpritesGCP: {
+		common: []string{"gcp/GCPCommon"},
+		dir:    "gcp",
+	},
+	SpritesKubernetes: {
+		common: []string{"kubernetes/k8s-sprites-unlabeled-25pct"},
+	},
+	SpritesDevicons: {
+		common: []string{"tupadr3/common"},
+		dir:    "tupadr3/devicons",
+	},
+	SpritesFontAwesome: {
+		common: []string{"tupadr3/common"},
+		dir:    "tupadr3/font-awesome",
+	},
+}
+
+// parseSprite splits a sprite reference into its library and the path of the
+// icon within that library. If the reference doesn't belong to a known
+// library, ok is false.
+func parseSprite(ref string) (lib SpriteLibrary, path string, ok bool) {
+	prefix, path, found := strings.Cut(ref, "/")
+	if !found {
+		return "", "", false
+	}
+	lib = SpriteLibrary(prefix)
+	if _, known := spriteLibraries[lib]; !known {
+		return "", "", false
+	}
+	return lib, path, true
+}
+
+// spriteName returns the name PlantUML uses for the sprite. Sprites from known
+// libraries are named after the last segment of their path while any other
+// reference is passed through unchanged.
+func spriteName(ref string) string {
+	if _, path, ok := parseSprite(ref); ok {
+		return path[strings.LastIndex(path, "/")+1:]
+	}
+	return ref
+}
+
+// spriteArg returns the trailing sprite argument for an element macro, or an
+// empty string if the element has no sprite.
+func spriteArg(ref string) string {
+	if ref == "" {
+		return ""
+	}
+	return fmt.Sprintf(`, $sprite="%s"`, spriteName(ref))
+}
+
+// spriteIncludes returns the standard library includes required by the
+// sprites used in els, limited to the enabled libraries.
+func spriteIncludes(els []Element, enabled []SpriteLibrary) []string {
+	var includes []string
+	seen := map[string]bool{}
+	add := func(include string) {
+		if !seen[include] {
+			seen[include] = true
+			includes = append(includes, include)
+		}
+	}
+
+	walk(els, func(el Element) {
+		lib, path, ok := parseSprite(sprite(el))
+		if !ok || !contains(enabled, lib) {
+			return
+		}
+		sl := spriteLibraries[lib]
+		for _, include := range sl.common {
+			add(include)
+		}
+		if sl.dir != "" {
+			add(sl.dir + "/" + path)
+		}
+	})
+
+	return includes
+}
+
+func sprite(el Element) string {
+	switch v := el.(type) {
+	case *Component:
+		return v.sprite
+	case *Container:
+		return v.sprite
+	case *Database:
+		return v.sprite
+	case *DeploymentNode:
+		return v.sprite
+	case *InfrastructureNode:
+		return v.sprite
+	case *Instance:
+		return sprite(v.of)
+	case *Person:
+		return v.sprite
+	case *Queue:
+		return v.sprite
+	case *System:
+		return v.sprite
+	}
+	return ""
+}
+
+func contains[T comparable](s []T, v T) bool {
+	for _, item := range s {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
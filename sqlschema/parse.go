@@ -0,0 +1,658 @@
+package sqlschema
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenQuoted
+	tokenString
+	tokenPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// is reports whether the token is the given unquoted keyword or punctuation.
+func (t token) is(s string) bool {
+	return (t.kind == tokenWord || t.kind == tokenPunct) && strings.EqualFold(t.text, s)
+}
+
+// tokenize splits DDL into statements of tokens. Comments are discarded and
+// statements are separated by semicolons.
+func tokenize(src string) ([][]token, error) {
+	var (
+		stmts [][]token
+		stmt  []token
+	)
+
+	rs := []rune(src)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '-' && i+1 < len(rs) && rs[i+1] == '-', r == '#':
+			for i < len(rs) && rs[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(rs) && rs[i+1] == '*':
+			end := index(rs, []rune("*/"), i+2)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment")
+			}
+			i = end + 2
+		case r == ';':
+			if len(stmt) > 0 {
+				stmts = append(stmts, stmt)
+			}
+			stmt = nil
+			i++
+		case r == '\'':
+			s, n, err := quoted(rs[i:], '\'', '\'')
+			if err != nil {
+				return nil, err
+			}
+			stmt = append(stmt, token{kind: tokenString, text: s})
+			i += n
+		case r == '"' || r == '`':
+			s, n, err := quoted(rs[i:], r, r)
+			if err != nil {
+				return nil, err
+			}
+			stmt = append(stmt, token{kind: tokenQuoted, text: s})
+			i += n
+		case r == '[' && i+1 < len(rs) && rs[i+1] != ']':
+			s, n, err := quoted(rs[i:], '[', ']')
+			if err != nil {
+				return nil, err
+			}
+			stmt = append(stmt, token{kind: tokenQuoted, text: s})
+			i += n
+		case r == '$' && dollarTag(rs[i:]) != "":
+			// Dollar-quoted strings such as function bodies may contain
+			// semicolons, so they are consumed whole.
+			tag := []rune(dollarTag(rs[i:]))
+			end := index(rs, tag, i+len(tag))
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated %s string", string(tag))
+			}
+			stmt = append(stmt, token{kind: tokenString, text: string(rs[i+len(tag) : end])})
+			i = end + len(tag)
+		case isWordRune(r):
+			start := i
+			for i < len(rs) && isWordRune(rs[i]) {
+				i++
+			}
+			stmt = append(stmt, token{kind: tokenWord, text: string(rs[start:i])})
+		default:
+			stmt = append(stmt, token{kind: tokenPunct, text: string(r)})
+			i++
+		}
+	}
+	if len(stmt) > 0 {
+		stmts = append(stmts, stmt)
+	}
+
+	return stmts, nil
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// index returns the index of the first instance of sub in rs at or after
+// from, or -1 if sub is not present.
+func index(rs, sub []rune, from int) int {
+outer:
+	for i := from; i+len(sub) <= len(rs); i++ {
+		for j, r := range sub {
+			if rs[i+j] != r {
+				continue outer
+			}
+		}
+		return i
+	}
+	return -1
+}
+
+// quoted reads a string delimited by open and close where a doubled close
+// escapes itself. It returns the unescaped string and the number of runes read.
+func quoted(rs []rune, open, close rune) (string, int, error) {
+	var b strings.Builder
+	for i := 1; i < len(rs); i++ {
+		if rs[i] != close {
+			b.WriteRune(rs[i])
+			continue
+		}
+		if i+1 < len(rs) && rs[i+1] == close && open == close {
+			b.WriteRune(close)
+			i++
+			continue
+		}
+		return b.String(), i + 1, nil
+	}
+	return "", 0, fmt.Errorf("unterminated %c", open)
+}
+
+// dollarTag returns the opening tag of a dollar-quoted string e.g. "$$" or
+// "$body$", if rs starts with one.
+func dollarTag(rs []rune) string {
+	for i := 1; i < len(rs); i++ {
+		switch {
+		case rs[i] == '$':
+			return string(rs[:i+1])
+		case rs[i] != '_' && !unicode.IsLetter(rs[i]) && (i == 1 || !unicode.IsDigit(rs[i])):
+			return ""
+		}
+	}
+	return ""
+}
+
+// name is a possibly schema-qualified table name.
+type name struct {
+	schema string
+	table  string
+}
+
+func (n name) String() string { return n.schema + "." + n.table }
+
+// model holds the tables and foreign keys described by a sequence of DDL
+// statements.
+type model struct {
+	defaultSchema  string
+	schemas        []string
+	schemaComments map[string]string
+	tables         []*table
+}
+
+type table struct {
+	name        name
+	comment     string
+	columns     []string
+	primaryKey  []string
+	foreignKeys []foreignKey
+}
+
+type foreignKey struct {
+	constraint string
+	columns    []string
+	ref        name
+	refColumns []string
+}
+
+func newModel(defaultSchema string) *model {
+	return &model{
+		defaultSchema:  defaultSchema,
+		schemaComments: map[string]string{},
+	}
+}
+
+func (m *model) table(n name) *table {
+	for _, t := range m.tables {
+		if t.name == n {
+			return t
+		}
+	}
+	return nil
+}
+
+func (m *model) addSchema(schema string) {
+	for _, s := range m.schemas {
+		if s == schema {
+			return
+		}
+	}
+	m.schemas = append(m.schemas, schema)
+}
+
+func (m *model) dropTable(n name) {
+	for i, t := range m.tables {
+		if t.name == n {
+			m.tables = append(m.tables[:i], m.tables[i+1:]...)
+			break
+		}
+	}
+	for _, t := range m.tables {
+		fks := t.foreignKeys[:0]
+		for _, fk := range t.foreignKeys {
+			if fk.ref != n {
+				fks = append(fks, fk)
+			}
+		}
+		t.foreignKeys = fks
+	}
+}
+
+func (m *model) renameTable(from, to name) {
+	if t := m.table(from); t != nil {
+		t.name = to
+		m.addSchema(to.schema)
+	}
+	for _, t := range m.tables {
+		for i := range t.foreignKeys {
+			if t.foreignKeys[i].ref == from {
+				t.foreignKeys[i].ref = to
+			}
+		}
+	}
+}
+
+// apply updates the model with the effects of a single statement. Statements
+// that don't affect tables, schemas or foreign keys are ignored.
+func (m *model) apply(toks []token) error {
+	p := &parser{toks: toks, defaultSchema: m.defaultSchema}
+	switch {
+	case p.accept("CREATE"):
+		p.accept("OR", "REPLACE")
+		p.accept("GLOBAL")
+		p.accept("LOCAL")
+		p.accept("TEMPORARY")
+		p.accept("TEMP")
+		p.accept("UNLOGGED")
+		switch {
+		case p.accept("TABLE"):
+			return m.createTable(p)
+		case p.accept("SCHEMA"):
+			p.accept("IF", "NOT", "EXISTS")
+			if s, ok := p.ident(); ok {
+				m.addSchema(s)
+			}
+		}
+	case p.accept("ALTER", "TABLE"):
+		return m.alterTable(p)
+	case p.accept("DROP", "TABLE"):
+		p.accept("IF", "EXISTS")
+		for {
+			n, ok := p.name()
+			if !ok {
+				break
+			}
+			m.dropTable(n)
+			if !p.accept(",") {
+				break
+			}
+		}
+	case p.accept("COMMENT", "ON"):
+		switch {
+		case p.accept("TABLE"):
+			n, _ := p.name()
+			if p.accept("IS") {
+				if t := m.table(n); t != nil {
+					t.comment = p.string()
+				}
+			}
+		case p.accept("SCHEMA"):
+			s, _ := p.ident()
+			if p.accept("IS") {
+				m.schemaComments[s] = p.string()
+			}
+		}
+	}
+	return nil
+}
+
+func (m *model) createTable(p *parser) error {
+	p.accept("IF", "NOT", "EXISTS")
+	n, ok := p.name()
+	if !ok {
+		return fmt.Errorf("CREATE TABLE: missing table name")
+	}
+
+	// Recreating an existing table is either an error or, with IF NOT EXISTS,
+	// has no effect.
+	if m.table(n) != nil {
+		return nil
+	}
+	t := &table{name: n}
+	m.tables = append(m.tables, t)
+	m.addSchema(n.schema)
+
+	if !p.accept("(") {
+		return nil
+	}
+	for _, def := range p.list() {
+		m.addDefinition(t, &parser{toks: def, defaultSchema: m.defaultSchema})
+	}
+	return nil
+}
+
+// addDefinition adds a column or table constraint from a CREATE TABLE or ALTER
+// TABLE ... ADD statement to t.
+func (m *model) addDefinition(t *table, p *parser) {
+	constraint := ""
+	if p.accept("CONSTRAINT") {
+		constraint, _ = p.ident()
+	}
+
+	switch {
+	case p.accept("PRIMARY", "KEY"):
+		t.primaryKey = p.columns()
+	case p.accept("FOREIGN", "KEY"):
+		cols := p.columns()
+		if p.accept("REFERENCES") {
+			ref, _ := p.name()
+			t.foreignKeys = append(t.foreignKeys, foreignKey{
+				constraint: constraint,
+				columns:    cols,
+				ref:        ref,
+				refColumns: p.columns(),
+			})
+		}
+	case p.peek("UNIQUE"), p.peek("CHECK"), p.peek("EXCLUDE"), p.peekIndex(), p.peek("LIKE"), p.peek("FULLTEXT"), p.peek("SPATIAL"):
+	default:
+		col, ok := p.ident()
+		if !ok {
+			return
+		}
+		t.columns = append(t.columns, col)
+
+		// The remainder of a column definition may include inline primary
+		// key and foreign key constraints.
+		for !p.done() {
+			if p.accept("CONSTRAINT") {
+				constraint, _ = p.ident()
+				continue
+			}
+			if p.accept("PRIMARY", "KEY") {
+				t.primaryKey = []string{col}
+				continue
+			}
+			if p.accept("REFERENCES") {
+				ref, _ := p.name()
+				t.foreignKeys = append(t.foreignKeys, foreignKey{
+					constraint: constraint,
+					columns:    []string{col},
+					ref:        ref,
+					refColumns: p.columns(),
+				})
+				continue
+			}
+			if p.accept("(") {
+				p.list()
+				continue
+			}
+			p.next()
+		}
+	}
+}
+
+func (m *model) alterTable(p *parser) error {
+	p.accept("IF", "EXISTS")
+	p.accept("ONLY")
+	n, ok := p.name()
+	if !ok {
+		return fmt.Errorf("ALTER TABLE: missing table name")
+	}
+	t := m.table(n)
+	if t == nil {
+		return nil
+	}
+
+	for _, action := range p.actions() {
+		ap := &parser{toks: action, defaultSchema: m.defaultSchema}
+		switch {
+		case ap.accept("ADD"):
+			ap.accept("COLUMN")
+			ap.accept("IF", "NOT", "EXISTS")
+			m.addDefinition(t, ap)
+		case ap.accept("DROP", "CONSTRAINT"):
+			ap.accept("IF", "EXISTS")
+			constraint, _ := ap.ident()
+			fks := t.foreignKeys[:0]
+			for _, fk := range t.foreignKeys {
+				if fk.constraint != constraint {
+					fks = append(fks, fk)
+				}
+			}
+			t.foreignKeys = fks
+		case ap.accept("DROP"):
+			ap.accept("COLUMN")
+			ap.accept("IF", "EXISTS")
+			col, _ := ap.ident()
+			t.columns = remove(t.columns, col)
+			fks := t.foreignKeys[:0]
+			for _, fk := range t.foreignKeys {
+				if len(remove(fk.columns, col)) == len(fk.columns) {
+					fks = append(fks, fk)
+				}
+			}
+			t.foreignKeys = fks
+		case ap.accept("RENAME", "TO"):
+			to, _ := ap.ident()
+			m.renameTable(t.name, name{schema: t.name.schema, table: to})
+		case ap.accept("RENAME"):
+			ap.accept("COLUMN")
+			from, _ := ap.ident()
+			if ap.accept("TO") {
+				to, _ := ap.ident()
+				rename(t.columns, from, to)
+				rename(t.primaryKey, from, to)
+				for _, fk := range t.foreignKeys {
+					rename(fk.columns, from, to)
+				}
+				for _, other := range m.tables {
+					for _, fk := range other.foreignKeys {
+						if fk.ref == t.name {
+							rename(fk.refColumns, from, to)
+						}
+					}
+				}
+			}
+		case ap.accept("SET", "SCHEMA"):
+			schema, _ := ap.ident()
+			m.renameTable(t.name, name{schema: schema, table: t.name.table})
+		}
+	}
+	return nil
+}
+
+// rename replaces from with to in s.
+func rename(s []string, from, to string) {
+	for i, el := range s {
+		if el == from {
+			s[i] = to
+		}
+	}
+}
+
+func remove(s []string, v string) []string {
+	out := make([]string, 0, len(s))
+	for _, el := range s {
+		if el != v {
+			out = append(out, el)
+		}
+	}
+	return out
+}
+
+type parser struct {
+	toks          []token
+	pos           int
+	defaultSchema string
+}
+
+func (p *parser) done() bool { return p.pos >= len(p.toks) }
+
+func (p *parser) next() token {
+	if p.done() {
+		return token{}
+	}
+	t := p.toks[p.pos]
+	p.pos++
+	return t
+}
+
+// peek reports whether the next token is the given keyword or punctuation.
+func (p *parser) peek(s string) bool {
+	return !p.done() && p.toks[p.pos].is(s)
+}
+
+// peekIndex reports whether the next tokens start a MySQL index definition e.g.
+// "KEY idx_email (email)" or "INDEX (email)". A column named key or index is
+// followed by its type instead, which may take arguments e.g. "key
+// varchar(64)", but never a list of columns.
+func (p *parser) peekIndex() bool {
+	if !p.peek("INDEX") && !p.peek("KEY") {
+		return false
+	}
+	rest := &parser{toks: p.toks[p.pos+1:]}
+	if rest.peek("(") || rest.peek("USING") {
+		return true
+	}
+	if _, ok := rest.ident(); !ok {
+		return false
+	}
+	if rest.peek("USING") {
+		return true
+	}
+	if !rest.accept("(") || rest.done() {
+		return false
+	}
+	switch t := rest.toks[rest.pos]; t.kind {
+	case tokenQuoted:
+		return true
+	case tokenWord:
+		return !unicode.IsDigit([]rune(t.text)[0])
+	}
+	return false
+}
+
+// accept consumes the given sequence of keywords if the next tokens match.
+func (p *parser) accept(seq ...string) bool {
+	if p.pos+len(seq) > len(p.toks) {
+		return false
+	}
+	for i, s := range seq {
+		if !p.toks[p.pos+i].is(s) {
+			return false
+		}
+	}
+	p.pos += len(seq)
+	return true
+}
+
+// ident reads an identifier. Unquoted identifiers are folded to lower case as
+// in PostgreSQL.
+func (p *parser) ident() (string, bool) {
+	if p.done() {
+		return "", false
+	}
+	switch t := p.toks[p.pos]; t.kind {
+	case tokenWord:
+		p.pos++
+		return strings.ToLower(t.text), true
+	case tokenQuoted:
+		p.pos++
+		return t.text, true
+	}
+	return "", false
+}
+
+// name reads a table name, which may be qualified by a schema. Unqualified
+// names belong to the default schema.
+func (p *parser) name() (name, bool) {
+	var parts []string
+	for {
+		part, ok := p.ident()
+		if !ok {
+			break
+		}
+		parts = append(parts, part)
+		if !p.accept(".") {
+			break
+		}
+	}
+	switch len(parts) {
+	case 0:
+		return name{}, false
+	case 1:
+		return name{schema: p.defaultSchema, table: parts[0]}, true
+	}
+	// Names qualified by a database as well as a schema only keep the last
+	// two parts.
+	return name{schema: parts[len(parts)-2], table: parts[len(parts)-1]}, true
+}
+
+// string reads a string literal.
+func (p *parser) string() string {
+	if !p.done() && p.toks[p.pos].kind == tokenString {
+		return p.next().text
+	}
+	return ""
+}
+
+// columns reads an optional parenthesized list of column names.
+func (p *parser) columns() []string {
+	if !p.accept("(") {
+		return nil
+	}
+	var cols []string
+	for _, item := range p.list() {
+		ip := &parser{toks: item}
+		if col, ok := ip.ident(); ok {
+			cols = append(cols, col)
+		}
+	}
+	return cols
+}
+
+// list reads the comma-separated items of a parenthesized group whose opening
+// parenthesis has already been consumed.
+func (p *parser) list() [][]token {
+	var (
+		items [][]token
+		item  []token
+		depth int
+	)
+	for !p.done() {
+		t := p.next()
+		switch {
+		case t.is("("):
+			depth++
+		case t.is(")"):
+			if depth == 0 {
+				return append(items, item)
+			}
+			depth--
+		case t.is(",") && depth == 0:
+			items = append(items, item)
+			item = nil
+			continue
+		}
+		item = append(item, t)
+	}
+	return append(items, item)
+}
+
+// actions reads the remaining comma-separated actions of an ALTER TABLE
+// statement.
+func (p *parser) actions() [][]token {
+	var (
+		actions [][]token
+		action  []token
+		depth   int
+	)
+	for !p.done() {
+		t := p.next()
+		switch {
+		case t.is("("):
+			depth++
+		case t.is(")"):
+			depth--
+		case t.is(",") && depth == 0:
+			actions = append(actions, action)
+			action = nil
+			continue
+		}
+		action = append(action, t)
+	}
+	return append(actions, action)
+}
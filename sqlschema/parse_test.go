@@ -0,0 +1,148 @@
+package sqlschema
+
+import (
+	"strings"
+	"testing"
+)
+
+// describe summarizes the tables of m, one per line, e.g.
+// "public.orders (id, customer_id) pk (id) fk (customer_id) → public.customers (id)".
+func describe(m *model) string {
+	var lines []string
+	for _, t := range m.tables {
+		line := t.name.String() + " (" + strings.Join(t.columns, ", ") + ")"
+		if len(t.primaryKey) > 0 {
+			line += " pk (" + strings.Join(t.primaryKey, ", ") + ")"
+		}
+		for _, fk := range t.foreignKeys {
+			line += " fk (" + strings.Join(fk.columns, ", ") + ") → " + fk.ref.String() + " (" + strings.Join(fk.refColumns, ", ") + ")"
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name string
+		ddl  string
+		want string
+	}{
+		{
+			name: "create table",
+			ddl:  `CREATE TABLE IF NOT EXISTS "Orders" (id serial PRIMARY KEY, total numeric(10, 2) NOT NULL DEFAULT 0);`,
+			want: "public.Orders (id, total) pk (id)",
+		},
+		{
+			name: "schema-qualified table",
+			ddl:  `CREATE SCHEMA billing; CREATE TABLE billing.invoices (id int, number text, PRIMARY KEY (id, number));`,
+			want: "billing.invoices (id, number) pk (id, number)",
+		},
+		{
+			name: "column named key",
+			ddl:  `CREATE TABLE settings (key text PRIMARY KEY, value text);`,
+			want: "public.settings (key, value) pk (key)",
+		},
+		{
+			name: "columns named key and index with arguments",
+			ddl:  "CREATE TABLE entries (`key` varchar(64), `index` int(11), KEY (`key`));",
+			want: "public.entries (key, index)",
+		},
+		{
+			name: "mysql indexes",
+			ddl: `CREATE TABLE users (
+				id int PRIMARY KEY,
+				email varchar(255),
+				KEY idx_email (email(32)),
+				INDEX idx_id USING BTREE (id),
+				UNIQUE KEY uq_email (email),
+				FULLTEXT KEY ft_email (email)
+			);`,
+			want: "public.users (id, email) pk (id)",
+		},
+		{
+			name: "inline foreign key",
+			ddl: `CREATE TABLE customers (id int PRIMARY KEY);
+				CREATE TABLE orders (id int PRIMARY KEY, customer_id int CONSTRAINT fk_customer REFERENCES customers (id));`,
+			want: "public.customers (id) pk (id)\npublic.orders (id, customer_id) pk (id) fk (customer_id) → public.customers (id)",
+		},
+		{
+			name: "table foreign key",
+			ddl: `CREATE TABLE crm.customers (id int, tenant_id int);
+				CREATE TABLE orders (id int, customer_id int, tenant_id int,
+					CONSTRAINT fk_customer FOREIGN KEY (customer_id, tenant_id) REFERENCES crm.customers (id, tenant_id));`,
+			want: "crm.customers (id, tenant_id)\npublic.orders (id, customer_id, tenant_id) fk (customer_id, tenant_id) → crm.customers (id, tenant_id)",
+		},
+		{
+			name: "alter table add and drop",
+			ddl: `CREATE TABLE customers (id int PRIMARY KEY);
+				CREATE TABLE orders (id int, legacy text);
+				ALTER TABLE orders ADD COLUMN customer_id int, DROP COLUMN legacy;
+				ALTER TABLE ONLY orders ADD CONSTRAINT fk_customer FOREIGN KEY (customer_id) REFERENCES customers (id);`,
+			want: "public.customers (id) pk (id)\npublic.orders (id, customer_id) fk (customer_id) → public.customers (id)",
+		},
+		{
+			name: "alter table drop constraint",
+			ddl: `CREATE TABLE customers (id int);
+				CREATE TABLE orders (id int, customer_id int CONSTRAINT fk_customer REFERENCES customers (id));
+				ALTER TABLE orders DROP CONSTRAINT IF EXISTS fk_customer;`,
+			want: "public.customers (id)\npublic.orders (id, customer_id)",
+		},
+		{
+			name: "alter table add column named key",
+			ddl: `CREATE TABLE settings (id int);
+				ALTER TABLE settings ADD key text, ADD KEY idx_key (key);`,
+			want: "public.settings (id, key)",
+		},
+		{
+			name: "drop column removes foreign key",
+			ddl: `CREATE TABLE customers (id int);
+				CREATE TABLE orders (id int, customer_id int REFERENCES customers);
+				ALTER TABLE orders DROP customer_id;`,
+			want: "public.customers (id)\npublic.orders (id)",
+		},
+		{
+			name: "rename",
+			ddl: `CREATE TABLE customers (id int);
+				CREATE TABLE orders (id int, cust int REFERENCES customers (id));
+				ALTER TABLE orders RENAME COLUMN cust TO customer_id;
+				ALTER TABLE customers RENAME id TO customer_id;
+				ALTER TABLE customers RENAME TO clients;
+				ALTER TABLE clients SET SCHEMA crm;`,
+			want: "crm.clients (customer_id)\npublic.orders (id, customer_id) fk (customer_id) → crm.clients (customer_id)",
+		},
+		{
+			name: "drop table removes foreign keys",
+			ddl: `CREATE TABLE customers (id int);
+				CREATE TABLE orders (id int, customer_id int REFERENCES customers (id));
+				DROP TABLE IF EXISTS customers;`,
+			want: "public.orders (id, customer_id)",
+		},
+		{
+			name: "ignored statements",
+			ddl: `-- A comment; with a semicolon
+				CREATE INDEX idx ON orders (id);
+				CREATE FUNCTION f() RETURNS trigger AS $$ BEGIN; END; $$ LANGUAGE plpgsql;
+				/* CREATE TABLE hidden (id int); */
+				CREATE TABLE orders (id int);`,
+			want: "public.orders (id)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmts, err := tokenize(tt.ddl)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m := newModel(DefaultSchema)
+			for _, stmt := range stmts {
+				if err := m.apply(stmt); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := describe(m); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
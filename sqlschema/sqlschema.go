@@ -0,0 +1,378 @@
+// Package sqlschema builds c4 components for the tables of a database from SQL
+// DDL.
+//
+// CREATE TABLE, ALTER TABLE, DROP TABLE, CREATE SCHEMA and COMMENT ON
+// statements are applied in order, so a directory of migrations produces the
+// same schema as running them against the database. Each table, or each schema
+// when using GroupBySchema, becomes a component within the boundary of the
+// Database, and foreign keys become relations between them:
+//
+//	res, err := sqlschema.ImportFiles(ctx, sqlschema.ImportArgs{Database: db}, "./migrations")
+//	if err != nil {
+//		return err
+//	}
+//	d.AddElement(ctx, res.Boundary)
+//	for _, rel := range res.Relations {
+//		d.NewRelation(ctx, rel)
+//	}
+//
+// The parser understands the common subset of PostgreSQL and MySQL DDL needed
+// to find tables and foreign keys. Other statements, such as indexes, views
+// and functions, are ignored.
+package sqlschema
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/haleyrc/c4"
+	"github.com/haleyrc/c4/internal/alias"
+)
+
+// Grouping controls what is converted into components.
+type Grouping int
+
+const (
+	// GroupByTable creates a component per table.
+	GroupByTable Grouping = iota
+
+	// GroupBySchema creates a component per schema, which is more readable
+	// for databases with many tables.
+	GroupBySchema
+)
+
+// DefaultSchema is the schema of unqualified table names unless otherwise
+// specified.
+const DefaultSchema = "public"
+
+// ImportArgs describes the parameters available for importing DDL.
+type ImportArgs struct {
+	// The database containing the tables. The generated components are added
+	// to its boundary.
+	Database *c4.Database
+
+	// Whether to create components per table or per schema.
+	GroupBy Grouping
+
+	// The schema of unqualified table names. Defaults to DefaultSchema.
+	DefaultSchema string
+
+	// An optional list of schemas to include. If empty, every schema is
+	// included.
+	Schemas []string
+
+	// An optional list of technologies for the components e.g. PostgreSQL.
+	// Defaults to the technologies of the database.
+	Technologies []string
+}
+
+// Result holds the elements generated from DDL.
+type Result struct {
+	// The boundary of the database containing the components.
+	Boundary c4.Boundary
+
+	// One component per table or schema in the order they were created.
+	Components []*c4.Component
+
+	// The components keyed by schema-qualified table name e.g.
+	// "public.orders", or by schema name when using GroupBySchema.
+	Tables map[string]*c4.Component
+
+	// Relations derived from foreign keys.
+	Relations []c4.RelationArgs
+}
+
+// ImportFiles is the same as Import, but reads the named files in order.
+// Directories are searched for files with a .sql extension, which are read in
+// order of the number that migrations are conventionally prefixed with, so
+// 2_orders.sql is read before 10_refunds.sql. Files ending in .down.sql are
+// skipped.
+func ImportFiles(ctx context.Context, args ImportArgs, names ...string) (*Result, error) {
+	var src strings.Builder
+	for _, name := range names {
+		files, err := sqlFiles(name)
+		if err != nil {
+			return nil, fmt.Errorf("sqlschema: import: %w", err)
+		}
+		for _, file := range files {
+			b, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("sqlschema: import: %w", err)
+			}
+			// Terminate each file so that a missing trailing semicolon
+			// doesn't merge statements across files.
+			src.Write(b)
+			src.WriteString("\n;\n")
+		}
+	}
+	return Import(ctx, strings.NewReader(src.String()), args)
+}
+
+// Import reads DDL from r and converts the resulting tables into components.
+func Import(ctx context.Context, r io.Reader, args ImportArgs) (*Result, error) {
+	if args.Database == nil {
+		return nil, fmt.Errorf("sqlschema: import: a database is required")
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("sqlschema: import: %w", err)
+	}
+	stmts, err := tokenize(string(b))
+	if err != nil {
+		return nil, fmt.Errorf("sqlschema: import: %w", err)
+	}
+
+	defaultSchema := args.DefaultSchema
+	if defaultSchema == "" {
+		defaultSchema = DefaultSchema
+	}
+	m := newModel(defaultSchema)
+	for _, stmt := range stmts {
+		if err := m.apply(stmt); err != nil {
+			return nil, fmt.Errorf("sqlschema: import: %w", err)
+		}
+	}
+
+	res := &Result{
+		Boundary: args.Database.Boundary(),
+		Tables:   map[string]*c4.Component{},
+	}
+	if args.GroupBy == GroupBySchema {
+		err = res.addSchemas(ctx, m, args)
+	} else {
+		err = res.addTables(ctx, m, args)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (res *Result) addTables(ctx context.Context, m *model, args ImportArgs) error {
+	for _, t := range m.tables {
+		if !included(t.name.schema, args.Schemas) {
+			continue
+		}
+
+		name := t.name.table
+		if t.name.schema != m.defaultSchema {
+			name = t.name.String()
+		}
+
+		properties := []c4.Property{{Name: "Columns", Value: strings.Join(t.columns, ", ")}}
+		if len(t.primaryKey) > 0 {
+			properties = append(properties, c4.Property{Name: "Primary Key", Value: strings.Join(t.primaryKey, ", ")})
+		}
+
+		c, err := res.newComponent(ctx, args, t.name.schema, t.name.table, name, t.comment, properties)
+		if err != nil {
+			return err
+		}
+		res.Tables[t.name.String()] = c
+	}
+
+	// Multiple foreign keys between the same tables are combined into a
+	// single relation.
+	for _, t := range m.tables {
+		src, ok := res.Tables[t.name.String()]
+		if !ok {
+			continue
+		}
+		keys := map[*c4.Component][]string{}
+		var dsts []*c4.Component
+		for _, fk := range t.foreignKeys {
+			dst, ok := res.Tables[fk.ref.String()]
+			if !ok {
+				continue
+			}
+			if _, seen := keys[dst]; !seen {
+				dsts = append(dsts, dst)
+			}
+			keys[dst] = append(keys[dst], fk.describe(""))
+		}
+		for _, dst := range dsts {
+			res.Relations = append(res.Relations, c4.RelationArgs{
+				Src:         src,
+				Dst:         dst,
+				Description: "References",
+				Properties:  []c4.Property{{Name: "Foreign Keys", Value: strings.Join(keys[dst], ", ")}},
+			})
+		}
+	}
+
+	return nil
+}
+
+func (res *Result) addSchemas(ctx context.Context, m *model, args ImportArgs) error {
+	tables := map[string][]string{}
+	for _, t := range m.tables {
+		tables[t.name.schema] = append(tables[t.name.schema], t.name.table)
+	}
+
+	for _, schema := range m.schemas {
+		if !included(schema, args.Schemas) {
+			continue
+		}
+		properties := []c4.Property{{Name: "Tables", Value: strings.Join(tables[schema], ", ")}}
+		c, err := res.newComponent(ctx, args, schema, "", schema, m.schemaComments[schema], properties)
+		if err != nil {
+			return err
+		}
+		res.Tables[schema] = c
+	}
+
+	keys := map[[2]*c4.Component][]string{}
+	var pairs [][2]*c4.Component
+	for _, t := range m.tables {
+		src, ok := res.Tables[t.name.schema]
+		if !ok {
+			continue
+		}
+		for _, fk := range t.foreignKeys {
+			dst, ok := res.Tables[fk.ref.schema]
+			if !ok || dst == src {
+				continue
+			}
+			pair := [2]*c4.Component{src, dst}
+			if _, seen := keys[pair]; !seen {
+				pairs = append(pairs, pair)
+			}
+			keys[pair] = append(keys[pair], fk.describe(t.name.table+"."))
+		}
+	}
+	for _, pair := range pairs {
+		res.Relations = append(res.Relations, c4.RelationArgs{
+			Src:         pair[0],
+			Dst:         pair[1],
+			Description: "References",
+			Properties:  []c4.Property{{Name: "Foreign Keys", Value: strings.Join(keys[pair], ", ")}},
+		})
+	}
+
+	return nil
+}
+
+func (res *Result) newComponent(ctx context.Context, args ImportArgs, schema, table, name, description string, properties []c4.Property) (*c4.Component, error) {
+	technologies := args.Technologies
+	if len(technologies) == 0 {
+		technologies = args.Database.Technologies()
+	}
+
+	c, err := c4.NewComponent(ctx, alias.Make(args.Database.ID(), schema, table), c4.ComponentArgs{
+		Name:         name,
+		Description:  description,
+		Technologies: technologies,
+		Shape:        c4.ShapeDatabase,
+		Properties:   properties,
+	})
+	if err != nil {
+		return nil, err
+	}
+	res.Boundary.AddElement(ctx, c)
+	res.Components = append(res.Components, c)
+	return c, nil
+}
+
+// describe returns a summary of the foreign key e.g.
+// "customer_id → crm.customers.id". The columns of the referencing table are
+// qualified by prefix.
+func (fk foreignKey) describe(prefix string) string {
+	return columnList(prefix, fk.columns) + " → " + columnList(fk.ref.String()+".", fk.refColumns)
+}
+
+// columnList qualifies one or more columns by prefix e.g. "orders.id" or
+// "orders.(id, tenant_id)".
+func columnList(prefix string, cols []string) string {
+	switch len(cols) {
+	case 0:
+		return strings.TrimSuffix(prefix, ".")
+	case 1:
+		return prefix + cols[0]
+	}
+	return prefix + "(" + strings.Join(cols, ", ") + ")"
+}
+
+func included(schema string, schemas []string) bool {
+	if len(schemas) == 0 {
+		return true
+	}
+	for _, s := range schemas {
+		if s == schema {
+			return true
+		}
+	}
+	return false
+}
+
+// sqlFiles returns the files to read for name. Directories are searched
+// recursively for .sql files excluding down migrations, which are sorted by
+// migrationLess.
+func sqlFiles(name string) ([]string, error) {
+	info, err := os.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{name}, nil
+	}
+
+	var files []string
+	err = filepath.WalkDir(name, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		lower := strings.ToLower(path)
+		if !d.IsDir() && strings.HasSuffix(lower, ".sql") && !strings.HasSuffix(lower, ".down.sql") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return migrationLess(files[i], files[j])
+	})
+	return files, nil
+}
+
+// migrationLess orders files by directory and then by the numeric prefix of
+// their names. Files without a numeric prefix, or with the same one, are
+// ordered lexically.
+func migrationLess(a, b string) bool {
+	if dirA, dirB := filepath.Dir(a), filepath.Dir(b); dirA != dirB {
+		return dirA < dirB
+	}
+	numA, numB := numericPrefix(filepath.Base(a)), numericPrefix(filepath.Base(b))
+	if numA != "" && numB != "" && numA != numB {
+		// Comparing by length first avoids overflowing on long timestamps.
+		if len(numA) != len(numB) {
+			return len(numA) < len(numB)
+		}
+		return numA < numB
+	}
+	return a < b
+}
+
+// numericPrefix returns the leading digits of name without any leading zeros,
+// or an empty string if name doesn't start with a digit.
+func numericPrefix(name string) string {
+	i := 0
+	for i < len(name) && name[i] >= '0' && name[i] <= '9' {
+		i++
+	}
+	if i == 0 {
+		return ""
+	}
+	if num := strings.TrimLeft(name[:i], "0"); num != "" {
+		return num
+	}
+	return "0"
+}
@@ -0,0 +1,110 @@
+package sqlschema
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/haleyrc/c4"
+)
+
+func TestSQLFiles(t *testing.T) {
+	files, err := sqlFiles(filepath.Join("testdata", "migrations"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, file := range files {
+		got = append(got, filepath.Base(file))
+	}
+	want := []string{"1_customers.sql", "2_orders.sql", "10_settings.sql"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestMigrationLess(t *testing.T) {
+	files := []string{
+		"b/1_x.sql",
+		"a/20240101120000_x.sql",
+		"a/10_b.sql",
+		"a/init.sql",
+		"a/2_x.sql",
+		"a/0002_y.sql",
+		"a/10_a.sql",
+	}
+	sort.SliceStable(files, func(i, j int) bool { return migrationLess(files[i], files[j]) })
+	want := []string{
+		"a/0002_y.sql",
+		"a/2_x.sql",
+		"a/10_a.sql",
+		"a/10_b.sql",
+		"a/20240101120000_x.sql",
+		"a/init.sql",
+		"b/1_x.sql",
+	}
+	if strings.Join(files, " ") != strings.Join(want, " ") {
+		t.Errorf("got %v, want %v", files, want)
+	}
+}
+
+func TestImportFiles(t *testing.T) {
+	ctx := context.Background()
+	db := c4.MustNewDatabase(ctx, "db", c4.DatabaseArgs{Name: "Database", Technologies: []string{"PostgreSQL"}})
+
+	res, err := ImportFiles(ctx, ImportArgs{Database: db}, filepath.Join("testdata", "migrations"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		table       string
+		id          string
+		name        string
+		description string
+		properties  string
+	}{
+		{"crm.customers", "db_crm_customers", "crm.customers", "People who place orders", "Columns: id, email; Primary Key: id"},
+		{"public.orders", "db_public_orders", "orders", "", "Columns: id, customer_id, total; Primary Key: id"},
+		{"public.settings", "db_public_settings", "settings", "", "Columns: key, value; Primary Key: key"},
+	}
+	if len(res.Components) != len(tests) {
+		t.Fatalf("got %d components, want %d", len(res.Components), len(tests))
+	}
+	for i, tt := range tests {
+		c := res.Tables[tt.table]
+		if c == nil {
+			t.Errorf("missing table %s", tt.table)
+			continue
+		}
+		if res.Components[i] != c {
+			t.Errorf("got component %d %s, want %s", i, res.Components[i].ID(), c.ID())
+		}
+		if c.ID() != tt.id || c.Name() != tt.name || c.Description() != tt.description {
+			t.Errorf("got %s %q %q, want %s %q %q", c.ID(), c.Name(), c.Description(), tt.id, tt.name, tt.description)
+		}
+		if c.Shape() != c4.ShapeDatabase {
+			t.Errorf("got shape %q for %s, want %q", c.Shape(), tt.table, c4.ShapeDatabase)
+		}
+		var props []string
+		for _, p := range c.Properties() {
+			props = append(props, p.Name+": "+p.Value)
+		}
+		if got := strings.Join(props, "; "); got != tt.properties {
+			t.Errorf("got properties %q for %s, want %q", got, tt.table, tt.properties)
+		}
+	}
+
+	if len(res.Relations) != 1 {
+		t.Fatalf("got %d relations, want 1", len(res.Relations))
+	}
+	rel := res.Relations[0]
+	if rel.Src != res.Tables["public.orders"] || rel.Dst != res.Tables["crm.customers"] {
+		t.Errorf("got relation from %s to %s, want orders to customers", rel.Src.ID(), rel.Dst.ID())
+	}
+	if len(rel.Properties) != 1 || rel.Properties[0].Value != "customer_id → crm.customers.id" {
+		t.Errorf("got properties %v, want the foreign key", rel.Properties)
+	}
+}
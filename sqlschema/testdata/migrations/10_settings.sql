@@ -0,0 +1,7 @@
+-- Runs after 2_orders.sql, so the column is added to an existing table.
+ALTER TABLE orders ADD COLUMN total numeric(10, 2);
+
+CREATE TABLE settings (
+    key text PRIMARY KEY,
+    value text
+)
@@ -0,0 +1,8 @@
+CREATE SCHEMA crm;
+
+CREATE TABLE crm.customers (
+    id serial PRIMARY KEY,
+    email text NOT NULL
+);
+
+COMMENT ON TABLE crm.customers IS 'People who place orders';
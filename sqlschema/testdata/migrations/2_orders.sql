@@ -0,0 +1,4 @@
+CREATE TABLE orders (
+    id serial PRIMARY KEY,
+    customer_id int NOT NULL REFERENCES crm.customers (id)
+);
@@ -14,6 +14,22 @@ type SystemArgs struct {
 
 	// Enables alternate styling reserved for external elements.
 	External bool
+
+	// An optional shape for the system e.g. ShapeDatabase for a third-party
+	// data platform.
+	Shape Shape
+
+	// An optional sprite to display on the system e.g.
+	// "aws/General/Client". See SpriteLibrary for the available libraries.
+	Sprite string
+
+	// An optional list of properties describing the system e.g. an owner or
+	// SLA. These are displayed as a table within the system.
+	Properties []Property
+
+	// An optional list of tags classifying the system e.g. "pci". Diagrams can
+	// be filtered by tag using Diagram.Filter.
+	Tags []string
 }
 
 // MustNewSystem is the same as NewSystem, but panics on any error.
@@ -32,6 +48,10 @@ func NewSystem(ctx context.Context, id string, args SystemArgs) (*System, error)
 		name:        args.Name,
 		description: args.Description,
 		external:    args.External,
+		sprite:      args.Sprite,
+		properties:  args.Properties,
+		tags:        args.Tags,
+		shape:       args.Shape,
 	}
 	return s, nil
 }
@@ -45,6 +65,10 @@ type System struct {
 	name        string
 	description string
 	external    bool
+	sprite      string
+	tags        []string
+	properties  []Property
+	shape       Shape
 }
 
 // Boundary returns a system boundary which can be used to group sub-containers
@@ -56,6 +80,27 @@ func (s *System) Boundary() Boundary {
 // ID satisfies the Element interface.
 func (s *System) ID() string { return s.id }
 
+// Name returns the human-readable name of the system.
+func (s *System) Name() string { return s.name }
+
+// Description returns the description of the system.
+func (s *System) Description() string { return s.description }
+
+// External reports whether the system is styled as an external element.
+func (s *System) External() bool { return s.external }
+
+// Shape returns the shape of the system.
+func (s *System) Shape() Shape { return s.shape }
+
+// Sprite returns the sprite displayed on the system, if any.
+func (s *System) Sprite() string { return s.sprite }
+
+// Properties returns the properties describing the system.
+func (s *System) Properties() []Property { return s.properties }
+
+// Tags returns the tags classifying the system.
+func (s *System) Tags() []string { return s.tags }
+
 type systemBoundary struct {
 	*System
 	elements []Element
@@ -64,3 +109,5 @@ type systemBoundary struct {
 func (sb *systemBoundary) AddElement(ctx context.Context, el Element) {
 	sb.elements = append(sb.elements, el)
 }
+
+func (sb *systemBoundary) Elements() []Element { return sb.elements }
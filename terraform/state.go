@@ -0,0 +1,155 @@
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/haleyrc/c4"
+)
+
+// resource holds a single managed resource instance regardless of which state
+// format it was read from.
+type resource struct {
+	Address string
+	Type    string
+	Name    string
+	Values  map[string]interface{}
+}
+
+// state holds both of the supported formats. Raw state files have a top-level
+// list of resources while "terraform show -json" nests them within modules.
+type state struct {
+	// terraform.tfstate
+	Resources []struct {
+		Module    string `json:"module"`
+		Mode      string `json:"mode"`
+		Type      string `json:"type"`
+		Name      string `json:"name"`
+		Instances []struct {
+			IndexKey   interface{}            `json:"index_key"`
+			Attributes map[string]interface{} `json:"attributes"`
+		} `json:"instances"`
+	} `json:"resources"`
+
+	// terraform show -json
+	Values *struct {
+		RootModule module `json:"root_module"`
+	} `json:"values"`
+}
+
+type module struct {
+	Resources []struct {
+		Address string                 `json:"address"`
+		Mode    string                 `json:"mode"`
+		Type    string                 `json:"type"`
+		Name    string                 `json:"name"`
+		Values  map[string]interface{} `json:"values"`
+	} `json:"resources"`
+	ChildModules []module `json:"child_modules"`
+}
+
+func decode(r io.Reader) ([]resource, error) {
+	var st state
+	if err := json.NewDecoder(r).Decode(&st); err != nil {
+		return nil, err
+	}
+
+	if st.Values != nil {
+		var resources []resource
+		collect(st.Values.RootModule, &resources)
+		return resources, nil
+	}
+
+	var resources []resource
+	for _, res := range st.Resources {
+		if res.Mode != "managed" {
+			continue
+		}
+		address := res.Type + "." + res.Name
+		if res.Module != "" {
+			address = res.Module + "." + address
+		}
+		for _, inst := range res.Instances {
+			resources = append(resources, resource{
+				Address: address + indexSuffix(inst.IndexKey),
+				Type:    res.Type,
+				Name:    res.Name,
+				Values:  inst.Attributes,
+			})
+		}
+	}
+	return resources, nil
+}
+
+func collect(m module, resources *[]resource) {
+	for _, res := range m.Resources {
+		if res.Mode != "managed" {
+			continue
+		}
+		*resources = append(*resources, resource{
+			Address: res.Address,
+			Type:    res.Type,
+			Name:    res.Name,
+			Values:  res.Values,
+		})
+	}
+	for _, child := range m.ChildModules {
+		collect(child, resources)
+	}
+}
+
+func indexSuffix(key interface{}) string {
+	switch key := key.(type) {
+	case nil:
+		return ""
+	case string:
+		return fmt.Sprintf("[%q]", key)
+	default:
+		return fmt.Sprintf("[%v]", key)
+	}
+}
+
+// name returns the most human-friendly name available for the resource,
+// preferring the Name tag used by the AWS console.
+func (r resource) name() string {
+	if tags, ok := r.Values["tags"].(map[string]interface{}); ok {
+		if name, ok := tags["Name"].(string); ok && name != "" {
+			return name
+		}
+	}
+	if name, ok := r.Values["name"].(string); ok && name != "" {
+		return name
+	}
+	return r.Address
+}
+
+// propertyAttributes lists the attributes that are shown as element
+// properties when present, in the order they are shown.
+var propertyAttributes = []string{
+	"availability_zone",
+	"cidr_block",
+	"engine",
+	"engine_version",
+	"fifo_queue",
+	"instance_class",
+	"instance_type",
+	"load_balancer_type",
+	"multi_az",
+}
+
+func (r resource) properties() []c4.Property {
+	properties := []c4.Property{{Name: "address", Value: r.Address}}
+
+	for _, attr := range propertyAttributes {
+		switch v := r.Values[attr].(type) {
+		case string:
+			if v != "" {
+				properties = append(properties, c4.Property{Name: attr, Value: v})
+			}
+		case bool, float64:
+			properties = append(properties, c4.Property{Name: attr, Value: fmt.Sprint(v)})
+		}
+	}
+	return properties
+}
@@ -0,0 +1,274 @@
+// Package terraform builds c4 deployment elements from Terraform state.
+//
+// Both the raw state file format (terraform.tfstate) and the output of
+// "terraform show -json" are supported. Each managed resource whose type
+// appears in the mapping table becomes a deployment node, infrastructure node,
+// database or queue. Resources are nested within their parent by following the
+// attributes named in the mapping e.g. an aws_instance is placed inside the
+// aws_subnet referenced by its subnet_id, which in turn is placed inside the
+// aws_vpc referenced by its vpc_id.
+//
+//	els, err := terraform.ImportFile(ctx, "terraform.tfstate", terraform.ImportArgs{})
+//	if err != nil {
+//		return err
+//	}
+//	for _, el := range els {
+//		d.AddElement(ctx, el)
+//	}
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/haleyrc/c4"
+	"github.com/haleyrc/c4/internal/alias"
+)
+
+// Kind represents the type of c4 element a resource is converted to.
+type Kind string
+
+const (
+	KindDeploymentNode     Kind = "deploymentNode"
+	KindInfrastructureNode Kind = "infrastructureNode"
+	KindDatabase           Kind = "database"
+	KindQueue              Kind = "queue"
+)
+
+// Mapping describes how resources of a single type are converted.
+type Mapping struct {
+	// The kind of element the resource becomes.
+	Kind Kind
+
+	// The human-readable type of the resource e.g. "VPC". This is used as the
+	// deployment node type or the element technology.
+	Type string
+
+	// The attributes that reference the parent of the resource, in order of
+	// preference. Attributes holding a list use the first value. The first
+	// attribute that refers to another resource imported as a deployment node
+	// determines where the resource is nested.
+	Parents []string
+}
+
+// DefaultMappings holds the mappings for common AWS resources.
+var DefaultMappings = map[string]Mapping{
+	"aws_vpc":    {Kind: KindDeploymentNode, Type: "VPC"},
+	"aws_subnet": {Kind: KindDeploymentNode, Type: "Subnet", Parents: []string{"vpc_id"}},
+	"aws_instance": {
+		Kind:    KindDeploymentNode,
+		Type:    "EC2 Instance",
+		Parents: []string{"subnet_id"},
+	},
+	"aws_ecs_cluster": {Kind: KindDeploymentNode, Type: "ECS Cluster"},
+	"aws_eks_cluster": {Kind: KindDeploymentNode, Type: "EKS Cluster"},
+	"aws_db_instance": {
+		Kind:    KindDatabase,
+		Type:    "Amazon RDS",
+		Parents: []string{"db_subnet_group_name"},
+	},
+	"aws_db_subnet_group": {
+		Kind:    KindDeploymentNode,
+		Type:    "DB Subnet Group",
+		Parents: []string{"vpc_id"},
+	},
+	"aws_rds_cluster": {
+		Kind:    KindDatabase,
+		Type:    "Amazon Aurora",
+		Parents: []string{"db_subnet_group_name"},
+	},
+	"aws_dynamodb_table":      {Kind: KindDatabase, Type: "Amazon DynamoDB"},
+	"aws_elasticache_cluster": {Kind: KindDatabase, Type: "Amazon ElastiCache"},
+	"aws_sqs_queue":           {Kind: KindQueue, Type: "Amazon SQS"},
+	"aws_sns_topic":           {Kind: KindQueue, Type: "Amazon SNS"},
+	"aws_msk_cluster":         {Kind: KindQueue, Type: "Amazon MSK"},
+	"aws_lb": {
+		Kind:    KindInfrastructureNode,
+		Type:    "Elastic Load Balancer",
+		Parents: []string{"vpc_id"},
+	},
+	"aws_alb": {
+		Kind:    KindInfrastructureNode,
+		Type:    "Application Load Balancer",
+		Parents: []string{"vpc_id"},
+	},
+	"aws_elb":         {Kind: KindInfrastructureNode, Type: "Classic Load Balancer", Parents: []string{"subnets"}},
+	"aws_nat_gateway": {Kind: KindInfrastructureNode, Type: "NAT Gateway", Parents: []string{"subnet_id"}},
+	"aws_internet_gateway": {
+		Kind:    KindInfrastructureNode,
+		Type:    "Internet Gateway",
+		Parents: []string{"vpc_id"},
+	},
+	"aws_route53_zone": {Kind: KindInfrastructureNode, Type: "Route 53 Hosted Zone"},
+}
+
+// ImportArgs describes the parameters available for importing Terraform state.
+type ImportArgs struct {
+	// Additional mappings keyed by resource type that take precedence over
+	// DefaultMappings.
+	Mappings map[string]Mapping
+
+	// Disables DefaultMappings so that only Mappings are used.
+	NoDefaultMappings bool
+}
+
+// ImportFile is the same as Import, but reads the named state file.
+func ImportFile(ctx context.Context, name string, args ImportArgs) ([]c4.Element, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("terraform: import: %w", err)
+	}
+	defer f.Close()
+
+	return Import(ctx, f, args)
+}
+
+// Import reads Terraform state from r and converts the mapped resources into
+// elements. Only the top-level elements are returned; resources with a parent
+// are added to the deployment node created for that parent.
+func Import(ctx context.Context, r io.Reader, args ImportArgs) ([]c4.Element, error) {
+	resources, err := decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("terraform: import: %w", err)
+	}
+
+	mappings := map[string]Mapping{}
+	if !args.NoDefaultMappings {
+		for k, v := range DefaultMappings {
+			mappings[k] = v
+		}
+	}
+	for k, v := range args.Mappings {
+		mappings[k] = v
+	}
+
+	type imported struct {
+		resource
+		mapping Mapping
+		element c4.Element
+	}
+
+	var all []*imported
+	byRef := map[string]*imported{}
+	for _, res := range resources {
+		m, ok := mappings[res.Type]
+		if !ok {
+			continue
+		}
+		el, err := newElement(ctx, res, m)
+		if err != nil {
+			return nil, err
+		}
+		imp := &imported{resource: res, mapping: m, element: el}
+		all = append(all, imp)
+
+		// Resources are referenced by ID in most cases, but some attributes
+		// such as db_subnet_group_name reference the resource name instead.
+		for _, key := range []string{"id", "arn", "name"} {
+			if ref, ok := res.Values[key].(string); ok && ref != "" {
+				if _, taken := byRef[ref]; !taken {
+					byRef[ref] = imp
+				}
+			}
+		}
+	}
+
+	parentOf := map[*imported]*imported{}
+	for _, imp := range all {
+		for _, attr := range imp.mapping.Parents {
+			p, ok := byRef[reference(imp.Values[attr])]
+			if !ok || p == imp {
+				continue
+			}
+			if _, ok := p.element.(*c4.DeploymentNode); ok {
+				parentOf[imp] = p
+				break
+			}
+		}
+	}
+
+	var top []c4.Element
+	for _, imp := range all {
+		parent, ok := parentOf[imp]
+		if !ok || nestedWithin(parentOf, parent, imp) {
+			top = append(top, imp.element)
+			continue
+		}
+		parent.element.(*c4.DeploymentNode).AddElement(ctx, imp.element)
+	}
+
+	return top, nil
+}
+
+// nestedWithin reports whether el appears in the chain of parents starting at
+// p. This prevents resources that reference each other from disappearing into
+// a cycle.
+func nestedWithin[T comparable](parentOf map[T]T, p, el T) bool {
+	seen := map[T]bool{}
+	for {
+		if p == el {
+			return true
+		}
+		if seen[p] {
+			return false
+		}
+		seen[p] = true
+		next, ok := parentOf[p]
+		if !ok {
+			return false
+		}
+		p = next
+	}
+}
+
+func newElement(ctx context.Context, res resource, m Mapping) (c4.Element, error) {
+	id := alias.Make(res.Address)
+	name := res.name()
+	properties := res.properties()
+
+	switch m.Kind {
+	case KindInfrastructureNode:
+		return c4.NewInfrastructureNode(ctx, id, c4.InfrastructureNodeArgs{
+			Name:         name,
+			Technologies: []string{m.Type},
+			Properties:   properties,
+		})
+	case KindDatabase:
+		return c4.NewDatabase(ctx, id, c4.DatabaseArgs{
+			Name:         name,
+			Technologies: []string{m.Type},
+			Properties:   properties,
+		})
+	case KindQueue:
+		return c4.NewQueue(ctx, id, c4.QueueArgs{
+			Name:         name,
+			Technologies: []string{m.Type},
+			Properties:   properties,
+		})
+	case KindDeploymentNode:
+		return c4.NewDeploymentNode(ctx, id, c4.DeploymentNodeArgs{
+			Name:       name,
+			Type:       m.Type,
+			Properties: properties,
+		})
+	}
+	return nil, fmt.Errorf("terraform: invalid kind %q for %s", m.Kind, res.Type)
+}
+
+// reference returns the value of a parent attribute. Attributes holding a list
+// of references use the first one.
+func reference(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []interface{}:
+		if len(v) > 0 {
+			if s, ok := v[0].(string); ok {
+				return s
+			}
+		}
+	}
+	return ""
+}
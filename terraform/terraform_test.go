@@ -0,0 +1,125 @@
+package terraform
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/haleyrc/c4"
+)
+
+// tree describes els, one per line, by their path through the enclosing
+// deployment nodes, kind, name and properties other than the address e.g.
+// "aws_vpc_main deploymentNode main cidr_block=10.0.0.0/16".
+func tree(els []c4.Element) string {
+	var lines []string
+	var visit func(prefix string, el c4.Element)
+	visit = func(prefix string, el c4.Element) {
+		line := prefix + el.ID() + " " + string(c4.KindOf(el))
+		if n, ok := el.(interface {
+			Name() string
+			Properties() []c4.Property
+		}); ok {
+			line += " " + n.Name()
+			for _, p := range n.Properties() {
+				if p.Name != "address" {
+					line += " " + p.Name + "=" + p.Value
+				}
+			}
+		}
+		lines = append(lines, line)
+		if n, ok := el.(*c4.DeploymentNode); ok {
+			for _, child := range n.Elements() {
+				visit(prefix+el.ID()+"/", child)
+			}
+		}
+	}
+	for _, el := range els {
+		visit("", el)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestImportFile(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		file string
+		args ImportArgs
+		want []string
+	}{
+		{
+			file: "terraform.tfstate",
+			want: []string{
+				"aws_vpc_main deploymentNode main cidr_block=10.0.0.0/16",
+				"aws_vpc_main/aws_subnet_private_0_ deploymentNode aws_subnet.private[0] availability_zone=eu-west-1a cidr_block=10.0.1.0/24",
+				`aws_vpc_main/aws_subnet_private_0_/aws_instance_web__a__ deploymentNode web-a instance_type=t3.micro`,
+				"aws_vpc_main/aws_subnet_private_1_ deploymentNode aws_subnet.private[1] availability_zone=eu-west-1b cidr_block=10.0.2.0/24",
+				"aws_vpc_main/aws_subnet_private_1_/module_network_aws_nat_gateway_nat infrastructureNode module.network.aws_nat_gateway.nat",
+				"aws_vpc_main/aws_db_subnet_group_db deploymentNode db-group",
+				"aws_vpc_main/aws_db_subnet_group_db/aws_db_instance_main database aws_db_instance.main engine=postgres engine_version=16.1 instance_class=db.t3.micro multi_az=true",
+				"aws_sqs_queue_jobs queue jobs fifo_queue=false",
+			},
+		},
+		{
+			file: "terraform.tfstate",
+			args: ImportArgs{
+				Mappings:          map[string]Mapping{"aws_s3_bucket": {Kind: KindDatabase, Type: "Amazon S3"}},
+				NoDefaultMappings: true,
+			},
+			want: []string{
+				"aws_s3_bucket_assets database aws_s3_bucket.assets",
+			},
+		},
+		{
+			file: "show.json",
+			want: []string{
+				"aws_vpc_main deploymentNode aws_vpc.main cidr_block=10.0.0.0/16",
+				"aws_vpc_main/module_queue_aws_lb_public infrastructureNode public load_balancer_type=application",
+			},
+		},
+	}
+	for _, tt := range tests {
+		els, err := ImportFile(ctx, filepath.Join("testdata", tt.file), tt.args)
+		if err != nil {
+			t.Fatalf("ImportFile(%s): %v", tt.file, err)
+		}
+		if got, want := tree(els), strings.Join(tt.want, "\n"); got != want {
+			t.Errorf("ImportFile(%s) got:\n%s\nwant:\n%s", tt.file, got, want)
+		}
+	}
+}
+
+func TestImportAddress(t *testing.T) {
+	ctx := context.Background()
+	els, err := ImportFile(ctx, filepath.Join("testdata", "terraform.tfstate"), ImportArgs{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := els[0].(*c4.DeploymentNode).Elements()[2].(*c4.DeploymentNode).Elements()[0].(*c4.Database)
+	if got := db.Properties()[0]; got.Name != "address" || got.Value != "aws_db_instance.main" {
+		t.Errorf("got first property %s=%s, want address=aws_db_instance.main", got.Name, got.Value)
+	}
+	if got := db.Technologies(); len(got) != 1 || got[0] != "Amazon RDS" {
+		t.Errorf("got technologies %v, want [Amazon RDS]", got)
+	}
+}
+
+func TestImportConcurrent(t *testing.T) {
+	// Imports share the package-level mappings and property attributes, so
+	// they must only ever be read.
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := ImportFile(ctx, filepath.Join("testdata", "terraform.tfstate"), ImportArgs{}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
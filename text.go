@@ -0,0 +1,349 @@
+package c4
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Text renders the Diagram as text to the provided writer, drawing elements as
+// boxes using the box-drawing characters supported by most terminals. This is
+// useful for inspecting a diagram without an image viewer, and for comparing
+// diagrams in tests and code review.
+//
+// Elements are stacked vertically, with boundaries and deployment nodes drawn
+// as frames around their children; boundaries use dashed lines. Relations are
+// drawn as arrows to the right of the elements, each labelled on the row where
+// it leaves its source. Relations involving elements that aren't part of the
+// diagram are omitted. Use WithASCII to draw using only ASCII characters.
+//
+// As with Mermaid, options that only affect PlantUML output, such as themes,
+// layouts and sprites, are ignored.
+func (d *Diagram) Text(ctx context.Context, w io.Writer) error {
+	if err := d.Validate(ctx); err != nil {
+		return err
+	}
+
+	chars := textUnicode
+	if d.ascii {
+		chars = textASCII
+	}
+
+	byID := map[string]*textNode{}
+	roots := textNodes(d.elements, chars, byID)
+
+	var relations []*relation
+	for _, rel := range d.renderedRelations() {
+		src, dst := byID[rel.src.ID()], byID[rel.dst.ID()]
+		if src == nil || dst == nil {
+			continue
+		}
+		src.attach++
+		dst.attach++
+		relations = append(relations, rel)
+	}
+
+	y := 0
+	right := 0
+	for _, n := range roots {
+		n.size()
+		n.place(0, y)
+		y += n.h + 1
+		if n.w > right {
+			right = n.w
+		}
+	}
+
+	var c textCanvas
+	for _, n := range roots {
+		n.draw(&c)
+	}
+
+	// Each relation is given its own vertical lane to the right of the
+	// elements. Shorter relations are placed in the lanes closest to the
+	// elements, which keeps most arrows short and reduces crossings.
+	type arrow struct {
+		src, dst       int // rows
+		srcCol, dstCol int
+		label          string
+	}
+	arrows := make([]arrow, len(relations))
+	for i, rel := range relations {
+		src, dst := byID[rel.src.ID()], byID[rel.dst.ID()]
+		a := arrow{src: src.row(), srcCol: src.x + src.w}
+		a.dst, a.dstCol = dst.row(), dst.x+dst.w
+		a.label = rel.description
+		if len(rel.technologies) > 0 {
+			a.label += " [" + strings.Join(rel.technologies, ", ") + "]"
+		}
+		arrows[i] = a
+	}
+	order := make([]int, len(arrows))
+	for i := range order {
+		order[i] = i
+	}
+	span := func(a arrow) int {
+		if a.src > a.dst {
+			return a.src - a.dst
+		}
+		return a.dst - a.src
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return span(arrows[order[i]]) < span(arrows[order[j]])
+	})
+
+	lanes := make([]int, len(arrows))
+	for lane, i := range order {
+		lanes[i] = right + 1 + 2*lane
+	}
+	labels := right + 2*len(arrows) + 1
+
+	for i, a := range arrows {
+		top, bottom := a.src, a.dst
+		if top > bottom {
+			top, bottom = bottom, top
+		}
+		for y := top + 1; y < bottom; y++ {
+			c.set(lanes[i], y, chars.lane)
+		}
+	}
+	for i, a := range arrows {
+		c.line(a.srcCol, lanes[i], a.src, chars)
+		c.line(a.dstCol+1, lanes[i], a.dst, chars)
+		c.set(a.dstCol, a.dst, chars.arrow)
+		if a.dst > a.src {
+			c.set(lanes[i], a.src, chars.down)
+			c.set(lanes[i], a.dst, chars.up)
+		} else {
+			c.set(lanes[i], a.src, chars.up)
+			c.set(lanes[i], a.dst, chars.down)
+		}
+		c.text(labels, a.src, a.label)
+	}
+
+	var buff bytes.Buffer
+
+	fmt.Fprintln(&buff, d.title)
+	fmt.Fprintln(&buff)
+	for _, row := range c.rows {
+		fmt.Fprintln(&buff, strings.TrimRight(string(row), " "))
+	}
+
+	if _, err := io.Copy(w, &buff); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// textBorder is the set of characters used to draw a box.
+type textBorder struct {
+	topLeft, topRight, bottomLeft, bottomRight rune
+	horizontal, vertical                       rune
+}
+
+type textChars struct {
+	element, node, boundary textBorder
+
+	line, lane, arrow, cross rune
+
+	// The corners joining a relation's horizontal line from the left to a
+	// lane that continues downwards or upwards.
+	down, up rune
+}
+
+var textUnicode = textChars{
+	element:  textBorder{'╭', '╮', '╰', '╯', '─', '│'},
+	node:     textBorder{'┌', '┐', '└', '┘', '─', '│'},
+	boundary: textBorder{'┌', '┐', '└', '┘', '╌', '╎'},
+	line:     '─',
+	lane:     '│',
+	arrow:    '◄',
+	cross:    '┼',
+	down:     '┐',
+	up:       '┘',
+}
+
+var textASCII = textChars{
+	element:  textBorder{'+', '+', '+', '+', '-', '|'},
+	node:     textBorder{'+', '+', '+', '+', '-', '|'},
+	boundary: textBorder{'+', '+', '+', '+', '.', ':'},
+	line:     '-',
+	lane:     '|',
+	arrow:    '<',
+	cross:    '+',
+	down:     '+',
+	up:       '+',
+}
+
+// vertical reports whether r is drawn as a vertical line, which relations
+// cross rather than overwrite.
+func (tc textChars) vertical(r rune) bool {
+	return r == tc.lane || r == tc.element.vertical || r == tc.node.vertical || r == tc.boundary.vertical
+}
+
+// textNode is an element drawn as a box, along with the boxes of its children
+// if it is a boundary or deployment node.
+type textNode struct {
+	lines    []string
+	border   textBorder
+	children []*textNode
+
+	// The number of relations attached to the node, and the number that have
+	// been given a row so far.
+	attach int
+	next   int
+
+	x, y, w, h int
+	rows       int
+}
+
+func textNodes(els []Element, chars textChars, byID map[string]*textNode) []*textNode {
+	var nodes []*textNode
+	for _, el := range els {
+		s := summarize(el)
+		n := &textNode{border: chars.element}
+
+		kind := s.kind
+		if s.external {
+			kind = "External " + kind
+		}
+		if len(s.technologies) > 0 {
+			kind += ": " + strings.Join(s.technologies, ", ")
+		}
+
+		switch v := el.(type) {
+		case *DeploymentNode:
+			n.border = chars.node
+			if v.instances > 1 {
+				s.name = fmt.Sprintf("%s x%d", s.name, v.instances)
+			}
+		case Boundary:
+			n.border = chars.boundary
+		}
+		n.lines = []string{s.name, "[" + kind + "]"}
+
+		if _, ok := el.(Boundary); ok {
+			n.children = textNodes(children(el), chars, byID)
+		} else if s.description != "" {
+			n.lines = append(n.lines, wrap(s.description, 40)...)
+		}
+
+		byID[el.ID()] = n
+		nodes = append(nodes, n)
+	}
+	return nodes
+}
+
+// size calculates the dimensions of the node. Boxes have a row for each line
+// of text and each attached relation, whichever is greater, and frames have a
+// blank row between their header and each of their children.
+func (n *textNode) size() {
+	width := 0
+	for _, line := range n.lines {
+		if w := utf8.RuneCountInString(line); w > width {
+			width = w
+		}
+	}
+	n.rows = len(n.lines)
+	if n.attach > n.rows {
+		n.rows = n.attach
+	}
+
+	n.h = n.rows + 2
+	if len(n.children) > 0 {
+		n.h++
+	}
+	for _, child := range n.children {
+		child.size()
+		if child.w > width {
+			width = child.w
+		}
+		n.h += child.h + 1
+	}
+	n.w = width + 4
+}
+
+func (n *textNode) place(x, y int) {
+	n.x, n.y = x, y
+	y += n.rows + 2
+	for _, child := range n.children {
+		child.place(x+2, y)
+		y += child.h + 1
+	}
+}
+
+func (n *textNode) draw(c *textCanvas) {
+	b := n.border
+	right, bottom := n.x+n.w-1, n.y+n.h-1
+	c.set(n.x, n.y, b.topLeft)
+	c.set(right, n.y, b.topRight)
+	c.set(n.x, bottom, b.bottomLeft)
+	c.set(right, bottom, b.bottomRight)
+	for x := n.x + 1; x < right; x++ {
+		c.set(x, n.y, b.horizontal)
+		c.set(x, bottom, b.horizontal)
+	}
+	for y := n.y + 1; y < bottom; y++ {
+		c.set(n.x, y, b.vertical)
+		c.set(right, y, b.vertical)
+	}
+	for i, line := range n.lines {
+		c.text(n.x+2, n.y+1+i, line)
+	}
+	for _, child := range n.children {
+		child.draw(c)
+	}
+}
+
+// row returns the row for the next relation attached to the node.
+func (n *textNode) row() int {
+	y := n.y + 1 + n.next
+	n.next++
+	return y
+}
+
+// textCanvas is a grid of characters that grows as characters are drawn.
+type textCanvas struct {
+	rows [][]rune
+}
+
+func (c *textCanvas) get(x, y int) rune {
+	if y >= len(c.rows) || x >= len(c.rows[y]) {
+		return ' '
+	}
+	return c.rows[y][x]
+}
+
+func (c *textCanvas) set(x, y int, r rune) {
+	for len(c.rows) <= y {
+		c.rows = append(c.rows, nil)
+	}
+	for len(c.rows[y]) <= x {
+		c.rows[y] = append(c.rows[y], ' ')
+	}
+	c.rows[y][x] = r
+}
+
+func (c *textCanvas) text(x, y int, s string) {
+	for _, r := range s {
+		c.set(x, y, r)
+		x++
+	}
+}
+
+// line draws a horizontal line on row y from x1 up to, but not including, x2,
+// crossing any vertical lines in the way.
+func (c *textCanvas) line(x1, x2, y int, chars textChars) {
+	for x := x1; x < x2; x++ {
+		if chars.vertical(c.get(x, y)) {
+			c.set(x, y, chars.cross)
+		} else {
+			c.set(x, y, chars.line)
+		}
+	}
+}